@@ -136,6 +136,63 @@ teardown() {
     server_query 0 "SET @@repo1_head=hashof('test_branch');SELECT * FROM one_pk ORDER by pk" ";pk,c1,c2\n0,None,None\n1,1,None\n2,2,2\n3,3,3"
 }
 
+@test "test merge function" {
+    skiponwindows "Has dependencies that are missing on the Jenkins Windows installation."
+
+    cd repo1
+    dolt sql -q "CREATE TABLE one_pk (
+        pk BIGINT NOT NULL COMMENT 'tag:0',
+        c1 BIGINT COMMENT 'tag:1',
+        PRIMARY KEY (pk)
+    )"
+    dolt add one_pk
+    dolt commit -m "created table"
+    dolt checkout -b feature
+    dolt sql -q "INSERT INTO one_pk (pk,c1) VALUES (1,1)"
+    dolt add one_pk
+    dolt commit -m "added row on feature"
+    dolt checkout master
+    dolt sql -q "INSERT INTO one_pk (pk,c1) VALUES (0,0)"
+    dolt add one_pk
+    dolt commit -m "added row on master"
+    start_sql_server repo1
+
+    # merge feature into master and check out the merge commit
+    server_query 0 "SET @@repo1_head=merge('feature');SELECT * FROM one_pk ORDER BY pk" ";pk,c1\n0,0\n1,1"
+
+    # the merge commit is dangling, so master is unchanged
+    server_query 0 "SELECT * FROM one_pk ORDER BY pk" "pk,c1\n0,0"
+}
+
+@test "test merge function with conflicts" {
+    skiponwindows "Has dependencies that are missing on the Jenkins Windows installation."
+
+    cd repo1
+    dolt sql -q "CREATE TABLE one_pk (
+        pk BIGINT NOT NULL COMMENT 'tag:0',
+        c1 BIGINT COMMENT 'tag:1',
+        PRIMARY KEY (pk)
+    )"
+    dolt add one_pk
+    dolt commit -m "created table"
+    dolt checkout -b feature
+    dolt sql -q "INSERT INTO one_pk (pk,c1) VALUES (0,1)"
+    dolt add one_pk
+    dolt commit -m "added row on feature"
+    dolt checkout master
+    dolt sql -q "INSERT INTO one_pk (pk,c1) VALUES (0,0)"
+    dolt add one_pk
+    dolt commit -m "added conflicting row on master"
+    start_sql_server repo1
+
+    run server_query 0 "SET @@repo1_head=merge('feature')" ""
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "has conflicts in tables: one_pk" ]] || false
+
+    # a failed merge leaves the session untouched
+    server_query 0 "SELECT * FROM one_pk ORDER BY pk" "pk,c1\n0,0"
+}
+
 @test "test multi db with use statements" {
     skiponwindows "Has dependencies that are missing on the Jenkins Windows installation."
 
@@ -0,0 +1,78 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+)
+
+// alignColumns wraps |to| in a Project node which orders its columns like the columns of |fromSch|,
+// and names them after the columns of |fromSch|. |mapping| maps the names of columns of |to| to the
+// names of columns of |fromSch|. Columns which are not in |mapping| are matched by name. Every
+// mapped column must exist, and every column of |fromSch| must be matched by a column of |to|.
+func alignColumns(fromSch sql.Schema, to sql.Node, mapping map[string]string) (sql.Node, error) {
+	toSch := to.Schema()
+	if len(fromSch) != len(toSch) {
+		return nil, fmt.Errorf("from query results have %d columns and to query results have %d columns", len(fromSch), len(toSch))
+	}
+
+	// toNames maps the lower case names of the columns of |fromSch| to the names of the columns of |to|
+	toNames := make(map[string]string, len(mapping))
+	for toName, fromName := range mapping {
+		if columnIndex(toSch, toName) < 0 {
+			return nil, fmt.Errorf("mapped column '%s' not found in to query results", toName)
+		}
+		if columnIndex(fromSch, fromName) < 0 {
+			return nil, fmt.Errorf("mapped column '%s' not found in from query results", fromName)
+		}
+		toNames[strings.ToLower(fromName)] = toName
+	}
+
+	exprs := make([]sql.Expression, len(fromSch))
+	for i, fromCol := range fromSch {
+		toName, ok := toNames[strings.ToLower(fromCol.Name)]
+		if !ok {
+			if isMappedColumn(mapping, fromCol.Name) {
+				// the column of |to| with this name is mapped to a different column of |fromSch|
+				return nil, fmt.Errorf("column '%s' of from query results has no matching column in to query results", fromCol.Name)
+			}
+			toName = fromCol.Name
+		}
+
+		idx := columnIndex(toSch, toName)
+		if idx < 0 {
+			return nil, fmt.Errorf("column '%s' of from query results has no matching column in to query results", fromCol.Name)
+		}
+		toCol := toSch[idx]
+		exprs[i] = expression.NewGetField(idx, toCol.Type, fromCol.Name, toCol.Nullable)
+	}
+
+	return plan.NewProject(exprs, to), nil
+}
+
+// isMappedColumn returns whether |mapping| maps a column named |name|, ignoring case.
+func isMappedColumn(mapping map[string]string, name string) bool {
+	for toName := range mapping {
+		if strings.EqualFold(toName, name) {
+			return true
+		}
+	}
+	return false
+}
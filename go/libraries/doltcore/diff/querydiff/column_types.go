@@ -0,0 +1,62 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+)
+
+// validateColumnTypes checks that the query results of both roots have the same number of columns,
+// and that the types of the columns at each position are comparable. Rows are compared with the
+// column types of the from results, which cannot compare the values of an incompatible type, such
+// as the values of a column whose type changed from INT to VARCHAR between the roots. The error
+// lists every incompatible column.
+func validateColumnTypes(from, to sql.Schema) error {
+	if len(from) != len(to) {
+		return fmt.Errorf("from query results have %d columns and to query results have %d columns", len(from), len(to))
+	}
+
+	var incompatible []string
+	for i := range from {
+		if !typesAreComparable(from[i].Type, to[i].Type) {
+			incompatible = append(incompatible, fmt.Sprintf("column %d ('%s') is %s in from results and %s in to results", i, from[i].Name, from[i].Type.String(), to[i].Type.String()))
+		}
+	}
+
+	if len(incompatible) > 0 {
+		return fmt.Errorf("query results have incompatible column types: %s", strings.Join(incompatible, "; "))
+	}
+
+	return nil
+}
+
+// typesAreComparable returns whether values of |from| and |to| can be compared with each other's
+// types. Numbers are comparable with numbers, text with text, and times with times, regardless of
+// their sizes. Other types must have the same SQL type.
+func typesAreComparable(from, to sql.Type) bool {
+	switch {
+	case sql.IsNumber(from) || sql.IsNumber(to):
+		return sql.IsNumber(from) && sql.IsNumber(to)
+	case sql.IsText(from) || sql.IsText(to):
+		return sql.IsText(from) && sql.IsText(to)
+	case sql.IsTime(from) || sql.IsTime(to):
+		return sql.IsTime(from) && sql.IsTime(to)
+	default:
+		return from.Type() == to.Type()
+	}
+}
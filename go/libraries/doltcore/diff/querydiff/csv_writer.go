@@ -0,0 +1,133 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+)
+
+const (
+	diffTypeColName = "diff_type"
+
+	csvModifiedFrom = "modified_from"
+	csvModifiedTo   = "modified_to"
+)
+
+// WriteCSV streams all remaining diffs to |w| as CSV. The first column of each line is the diff type, followed by
+// the query's columns. Modified rows are written as two lines, "modified_from" followed by "modified_to". As with
+// Dolt's CSV export, NULL values are written as empty fields and empty strings are written as "".
+func (qd *QueryDiffer) WriteCSV(w io.Writer) error {
+	wr := bufio.NewWriter(w)
+
+	header := make([]*string, 0, len(qd.sch)+1)
+	header = append(header, strPtr(diffTypeColName))
+	for _, col := range qd.sch {
+		header = append(header, strPtr(col.Name))
+	}
+
+	err := writeCSVLine(wr, header)
+	if err != nil {
+		return err
+	}
+
+	for {
+		rd, err := qd.NextRowDiff()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		switch rd.Type {
+		case Added:
+			err = writeCSVRow(wr, rd.Type.String(), rd.To)
+		case Removed:
+			err = writeCSVRow(wr, rd.Type.String(), rd.From)
+		case Modified:
+			err = writeCSVRow(wr, csvModifiedFrom, rd.From)
+			if err == nil {
+				err = writeCSVRow(wr, csvModifiedTo, rd.To)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return wr.Flush()
+}
+
+func writeCSVRow(wr *bufio.Writer, diffType string, r sql.Row) error {
+	fields := make([]*string, 0, len(r)+1)
+	fields = append(fields, strPtr(diffType))
+
+	for _, v := range r {
+		if v == nil {
+			fields = append(fields, nil)
+			continue
+		}
+
+		fields = append(fields, strPtr(fmt.Sprintf("%v", v)))
+	}
+
+	return writeCSVLine(wr, fields)
+}
+
+// writeCSVLine writes a single CSV line. nil fields are written as empty fields, while empty strings are quoted.
+func writeCSVLine(wr *bufio.Writer, fields []*string) error {
+	for i, field := range fields {
+		if i > 0 {
+			if err := wr.WriteByte(','); err != nil {
+				return err
+			}
+		}
+
+		if field == nil {
+			continue
+		}
+
+		if !csvFieldNeedsQuotes(*field) {
+			if _, err := wr.WriteString(*field); err != nil {
+				return err
+			}
+			continue
+		}
+
+		quoted := `"` + strings.Replace(*field, `"`, `""`, -1) + `"`
+		if _, err := wr.WriteString(quoted); err != nil {
+			return err
+		}
+	}
+
+	return wr.WriteByte('\n')
+}
+
+func csvFieldNeedsQuotes(field string) bool {
+	if field == "" {
+		return true
+	}
+
+	return field[0] == ' ' || strings.ContainsAny(field, ",\"\r\n")
+}
+
+func strPtr(s string) *string {
+	return &s
+}
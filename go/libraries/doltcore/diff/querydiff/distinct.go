@@ -0,0 +1,101 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+)
+
+// isDistinct returns whether |n| removes duplicate rows from the results of its child.
+func isDistinct(n sql.Node) bool {
+	switch n.(type) {
+	case *plan.Distinct, *plan.OrderedDistinct:
+		return true
+	default:
+		return false
+	}
+}
+
+// distinctSort returns the sort node whose rows are deduplicated in place of the distinct node
+// |distinct|. If the child of |distinct| is a sort node, such as that of an ORDER BY query, its rows
+// are diffed in the order of the query. Otherwise the distinct results are diffed as a set: the rows
+// of the child are sorted by all of their columns, in ascending order, which is the order in which
+// the QueryDiffer returns them.
+func distinctSort(distinct sql.Node) *plan.Sort {
+	child := distinct.Children()[0]
+	if sort, ok := child.(*plan.Sort); ok {
+		return sort
+	}
+
+	return sortByAllColumns(child)
+}
+
+// sortByAllColumns returns a sort node which sorts the rows of |child| by all of their columns, in
+// ascending order.
+func sortByAllColumns(child sql.Node) *plan.Sort {
+	sch := child.Schema()
+	fields := make([]plan.SortField, len(sch))
+	for i, col := range sch {
+		fields[i] = plan.SortField{
+			Column:       expression.NewGetFieldWithTable(i, col.Type, col.Source, col.Name, col.Nullable),
+			Order:        plan.Ascending,
+			NullOrdering: plan.NullsFirst,
+		}
+	}
+
+	return plan.NewSort(fields, child)
+}
+
+// distinctRowIter wraps a RowIter whose equal rows are adjacent, such as a tiebreakIter, and skips
+// each row which is equal to the row before it.
+type distinctRowIter struct {
+	iter sql.RowIter
+	sch  sql.Schema
+	last sql.Row
+}
+
+var _ sql.RowIter = &distinctRowIter{}
+
+func newDistinctRowIter(iter sql.RowIter, sch sql.Schema) *distinctRowIter {
+	return &distinctRowIter{iter: iter, sch: sch}
+}
+
+func (di *distinctRowIter) Next() (sql.Row, error) {
+	for {
+		r, err := di.iter.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if di.last != nil {
+			eq, err := rowsEqual(di.sch, nil, nil, di.last, r)
+			if err != nil {
+				return nil, err
+			}
+			if eq {
+				continue
+			}
+		}
+
+		di.last = r
+		return r, nil
+	}
+}
+
+func (di *distinctRowIter) Close() error {
+	return di.iter.Close()
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"errors"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+)
+
+var errNoGroupKey = errors.New("group diffs require a QueryDiffer created with key columns")
+
+// GroupDiff is the diff of a single group of the results of a GROUP BY query.
+type GroupDiff struct {
+	// Key holds the values of the group key columns.
+	Key sql.Row
+	// Type is Added or Removed if the group is only in the results of one root, and Modified if its
+	// aggregates changed.
+	Type DiffType
+	// Deltas holds the changes of the group's aggregate columns. A modified group has a delta for
+	// each aggregate that changed, and an added or removed group has a delta for every aggregate.
+	Deltas []AggregateDelta
+}
+
+// AggregateDelta is the change of one aggregate column of a group.
+type AggregateDelta struct {
+	Column string
+	From   interface{}
+	To     interface{}
+	// Delta is To minus From, if Numeric is true. Numeric is false if either value is NULL or not
+	// a number, including when the group was added or removed.
+	Delta   float64
+	Numeric bool
+}
+
+// groupMatcher builds GroupDiffs from the RowDiffs of a GROUP BY query's results. The columns of
+// the results are split into the group key columns and the aggregate columns.
+type groupMatcher struct {
+	sch     sql.Schema
+	keyIdxs []int
+	aggIdxs []int
+}
+
+func newGroupMatcher(sch sql.Schema, keyCols []string) (*groupMatcher, error) {
+	keyIdxs, err := keyIndexes(sch, keyCols)
+	if err != nil {
+		return nil, err
+	}
+
+	isKey := make(map[int]bool, len(keyIdxs))
+	for _, idx := range keyIdxs {
+		isKey[idx] = true
+	}
+
+	var aggIdxs []int
+	for i := range sch {
+		if !isKey[i] {
+			aggIdxs = append(aggIdxs, i)
+		}
+	}
+
+	return &groupMatcher{sch: sch, keyIdxs: keyIdxs, aggIdxs: aggIdxs}, nil
+}
+
+func (gm *groupMatcher) match(rd RowDiff) (GroupDiff, error) {
+	keyRow := rd.From
+	if keyRow == nil {
+		keyRow = rd.To
+	}
+
+	key := make(sql.Row, len(gm.keyIdxs))
+	for i, idx := range gm.keyIdxs {
+		key[i] = keyRow[idx]
+	}
+
+	gd := GroupDiff{Key: key, Type: rd.Type}
+	for _, idx := range gm.aggIdxs {
+		var from, to interface{}
+		if rd.From != nil {
+			from = rd.From[idx]
+		}
+		if rd.To != nil {
+			to = rd.To[idx]
+		}
+
+		if rd.From != nil && rd.To != nil {
+			cmp, err := gm.sch[idx].Type.Compare(from, to)
+			if err != nil {
+				return GroupDiff{}, err
+			}
+			if cmp == 0 {
+				continue
+			}
+		}
+
+		gd.Deltas = append(gd.Deltas, newAggregateDelta(gm.sch[idx], from, to))
+	}
+
+	return gd, nil
+}
+
+func newAggregateDelta(col *sql.Column, from, to interface{}) AggregateDelta {
+	delta := AggregateDelta{Column: col.Name, From: from, To: to}
+	if from == nil || to == nil || !sql.IsNumber(col.Type) {
+		return delta
+	}
+
+	fromF, err := sql.Float64.Convert(from)
+	if err != nil {
+		return delta
+	}
+	toF, err := sql.Float64.Convert(to)
+	if err != nil {
+		return delta
+	}
+
+	delta.Delta, delta.Numeric = toF.(float64)-fromF.(float64), true
+	return delta
+}
+
+// NextGroupDiff returns the next diff of the results of a GROUP BY query as a GroupDiff. The key
+// columns of the QueryDiffer, set with WithKeyColumns, are the group key: rows are paired up by
+// group, and every other column of the results is treated as an aggregate, whose changes are
+// reported as AggregateDeltas. The key columns must be in the results of the query. Returns io.EOF
+// once all diffs have been returned.
+func (qd *QueryDiffer) NextGroupDiff() (GroupDiff, error) {
+	if len(qd.keyColumns) == 0 {
+		return GroupDiff{}, errNoGroupKey
+	}
+
+	if qd.groups == nil {
+		gm, err := newGroupMatcher(qd.sch, qd.keyColumns)
+		if err != nil {
+			return GroupDiff{}, err
+		}
+		qd.groups = gm
+	}
+
+	rd, err := qd.NextRowDiff()
+	if err != nil {
+		return GroupDiff{}, err
+	}
+
+	return qd.groups.match(rd)
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+)
+
+// isGroupFilter returns whether |n| filters the groups of a GROUP BY node, as the HAVING clause of
+// a query does. The analyzer places the filter above the Project node of the query's select list,
+// so the GROUP BY node is found beneath any Project or Filter nodes. Without an ORDER BY clause the
+// groups are returned in no particular order, so the groups which pass the filter are diffed as a
+// set, sorted by all of their columns.
+func isGroupFilter(n sql.Node) bool {
+	switch n := n.(type) {
+	case *plan.Having:
+		return isGroupByBelow(n.Child)
+	case *plan.Filter:
+		return isGroupByBelow(n.Child)
+	default:
+		return false
+	}
+}
+
+// isGroupByBelow returns whether |n| is a GROUP BY node, or has one beneath a chain of Project and
+// Filter nodes, which pass along one row for each group.
+func isGroupByBelow(n sql.Node) bool {
+	switch n := n.(type) {
+	case *plan.GroupBy:
+		return true
+	case *plan.Project:
+		return isGroupByBelow(n.Child)
+	case *plan.Filter:
+		return isGroupByBelow(n.Child)
+	case *plan.Having:
+		return isGroupByBelow(n.Child)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"fmt"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// sortByKey returns a copy of |sort| which sorts its rows by the columns named in |keyCols|, in
+// ascending order, rather than by the sort fields of the query. Diffing rows sorted by key pairs
+// up the rows of each root with the same key, so that a row whose non-key columns change is
+// reported as modified rather than as removed and added.
+func sortByKey(sort *plan.Sort, keyCols []string) (*plan.Sort, error) {
+	orders := make([]plan.SortOrder, len(keyCols))
+	for i := range orders {
+		orders[i] = plan.Ascending
+	}
+
+	fields, err := SortFieldsFromColumns(sort.Schema(), keyCols, orders)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewSort(fields, sort.Child), nil
+}
+
+// keyIndexes returns the indexes of the columns of |sch| named in |keyCols|.
+func keyIndexes(sch sql.Schema, keyCols []string) ([]int, error) {
+	idxs := make([]int, len(keyCols))
+	for i, name := range keyCols {
+		idxs[i] = columnIndex(sch, name)
+		if idxs[i] < 0 {
+			return nil, fmt.Errorf("column '%s' not found", name)
+		}
+	}
+	return idxs, nil
+}
+
+// uniqueKeyIter wraps a RowIter whose rows are sorted by key, and returns an error if a row has a
+// NULL key or the same key as the row before it. Rows cannot be matched by a key which does not
+// identify a single row.
+type uniqueKeyIter struct {
+	iter    sql.RowIter
+	keyCols []int
+	last    types.Tuple
+	hasLast bool
+}
+
+var _ sql.RowIter = &uniqueKeyIter{}
+
+func newUniqueKeyIter(iter sql.RowIter, keyCols []int) *uniqueKeyIter {
+	return &uniqueKeyIter{iter: iter, keyCols: keyCols}
+}
+
+func (ki *uniqueKeyIter) Next() (sql.Row, error) {
+	r, err := ki.iter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := RowKeyTuple(types.Format_Default, r, ki.keyCols)
+	if err != nil {
+		return nil, err
+	}
+
+	if ki.hasLast && key.Equals(ki.last) {
+		keyVals := make([]interface{}, len(ki.keyCols))
+		for i, col := range ki.keyCols {
+			keyVals[i] = r[col]
+		}
+		return nil, fmt.Errorf("key %v is not unique in query results", keyVals)
+	}
+
+	ki.last, ki.hasLast = key, true
+	return r, nil
+}
+
+func (ki *uniqueKeyIter) Close() error {
+	return ki.iter.Close()
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"io"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+)
+
+// noLimit is the limit of a sortNodeDiffer which diffs every row of its sort nodes.
+const noLimit = -1
+
+// limitRowIter returns at most |remaining| rows of |iter|.
+type limitRowIter struct {
+	iter      sql.RowIter
+	remaining int64
+}
+
+var _ sql.RowIter = &limitRowIter{}
+
+func newLimitRowIter(iter sql.RowIter, limit int64) *limitRowIter {
+	return &limitRowIter{iter: iter, remaining: limit}
+}
+
+func (li *limitRowIter) Next() (sql.Row, error) {
+	if li.remaining <= 0 {
+		return nil, io.EOF
+	}
+
+	r, err := li.iter.Next()
+	if err != nil {
+		return nil, err
+	}
+
+	li.remaining--
+	return r, nil
+}
+
+func (li *limitRowIter) Close() error {
+	return li.iter.Close()
+}
+
+// limitsSortNode returns whether |limit| limits the rows of a sort node, with only Project nodes
+// in between. Project nodes produce one row for each of their input rows, so limiting the rows of
+// the sort node limits the query results by the same amount.
+func limitsSortNode(limit *plan.Limit) bool {
+	n := limit.Child
+	for {
+		switch nn := n.(type) {
+		case *plan.Sort:
+			return true
+		case *plan.Project:
+			n = nn.Child
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"io"
+	"testing"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRowIter counts the rows pulled from the RowIter it wraps.
+type countingRowIter struct {
+	iter   sql.RowIter
+	pulled int
+}
+
+func (ci *countingRowIter) Next() (sql.Row, error) {
+	r, err := ci.iter.Next()
+	if err == nil {
+		ci.pulled++
+	}
+	return r, err
+}
+
+func (ci *countingRowIter) Close() error {
+	return ci.iter.Close()
+}
+
+func TestLimitRowIter(t *testing.T) {
+	rows := []sql.Row{{int64(0)}, {int64(1)}, {int64(2)}, {int64(3)}}
+
+	for _, limit := range []int64{0, 1, 2, 4, 10} {
+		child := &countingRowIter{iter: sql.RowsToRowIter(rows...)}
+		li := newLimitRowIter(child, limit)
+
+		actual := []sql.Row{}
+		for {
+			r, err := li.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			actual = append(actual, r)
+		}
+
+		// reading past the limit does not pull more rows from the child
+		_, err := li.Next()
+		assert.Equal(t, io.EOF, err)
+
+		expected := int(limit)
+		if expected > len(rows) {
+			expected = len(rows)
+		}
+		assert.Equal(t, rows[:expected], actual, "limit: %d", limit)
+		assert.Equal(t, expected, child.pulled, "limit: %d", limit)
+		require.NoError(t, li.Close())
+	}
+}
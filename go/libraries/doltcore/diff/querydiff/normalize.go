@@ -0,0 +1,134 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+)
+
+// normalizeSort returns a copy of |sort| which orders the string values of the columns named in
+// |norms| by their normalized values. The rows produced by the sort node are not changed, so the
+// QueryDiffer emits the values of each root as they are, while the nodeDiffer orders and merges
+// them by their normalized values, which rowsEqual also compares. Every column named in |norms|
+// must exist in the schema of |sort|.
+func normalizeSort(sort *plan.Sort, norms map[string]StringNormalization) (*plan.Sort, error) {
+	sch := sort.Schema()
+	for name := range norms {
+		if columnIndex(sch, name) < 0 {
+			return nil, fmt.Errorf("column '%s' not found", name)
+		}
+	}
+	colNorms := columnNormalizations(sch, norms)
+
+	return wrapSortColumns(sort, func(gf *expression.GetField) sql.Expression {
+		if colNorms[gf.Index()] == (StringNormalization{}) {
+			return gf
+		}
+		return &normalizedString{expression.UnaryExpression{Child: gf}, colNorms[gf.Index()]}
+	})
+}
+
+// wrapSortColumns returns a copy of |sort| whose sort fields have each reference to a column of
+// |sort| replaced with the expression returned by |wrap|.
+func wrapSortColumns(sort *plan.Sort, wrap func(gf *expression.GetField) sql.Expression) (*plan.Sort, error) {
+	numCols := len(sort.Schema())
+	fields := make([]plan.SortField, len(sort.SortFields))
+	for i, sf := range sort.SortFields {
+		col, err := expression.TransformUp(sf.Column, func(e sql.Expression) (sql.Expression, error) {
+			gf, ok := e.(*expression.GetField)
+			if !ok || gf.Index() >= numCols {
+				return e, nil
+			}
+			return wrap(gf), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = plan.SortField{Column: col, Order: sf.Order, NullOrdering: sf.NullOrdering}
+	}
+
+	return plan.NewSort(fields, sort.Child), nil
+}
+
+// columnNormalizations returns the StringNormalization of each column of |sch|, or nil if |norms|
+// is empty. Columns which are not named in |norms| have the zero StringNormalization, which leaves
+// their values unchanged.
+func columnNormalizations(sch sql.Schema, norms map[string]StringNormalization) []StringNormalization {
+	if len(norms) == 0 {
+		return nil
+	}
+
+	colNorms := make([]StringNormalization, len(sch))
+	for i, col := range sch {
+		colNorms[i] = norms[strings.ToLower(col.Name)]
+	}
+	return colNorms
+}
+
+// normalizeRow returns a copy of |row| with the normalization of each column of |norms| applied to
+// its string values.
+func normalizeRow(norms []StringNormalization, row sql.Row) sql.Row {
+	normalized := make(sql.Row, len(row))
+	for i, v := range row {
+		if str, ok := v.(string); ok && i < len(norms) {
+			v = norms[i].apply(str)
+		}
+		normalized[i] = v
+	}
+	return normalized
+}
+
+// normalizedString is an expression which applies a StringNormalization to the string values
+// of its child. Values of other types are returned unchanged.
+type normalizedString struct {
+	expression.UnaryExpression
+	norm StringNormalization
+}
+
+var _ sql.Expression = &normalizedString{}
+
+func (ns *normalizedString) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	val, err := ns.Child.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		return val, nil
+	}
+
+	return ns.norm.apply(str), nil
+}
+
+func (ns *normalizedString) Type() sql.Type {
+	return ns.Child.Type()
+}
+
+func (ns *normalizedString) String() string {
+	return fmt.Sprintf("NORMALIZE(%s)", ns.Child)
+}
+
+func (ns *normalizedString) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(ns, len(children), 1)
+	}
+	return &normalizedString{expression.UnaryExpression{Child: children[0]}, ns.norm}, nil
+}
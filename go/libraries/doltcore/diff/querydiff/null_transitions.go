@@ -0,0 +1,64 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import "github.com/liquidata-inc/go-mysql-server/sql"
+
+// NullTransition counts the modified rows whose value of a column changed to or from NULL.
+type NullTransition struct {
+	// Added is the number of rows whose value became NULL.
+	Added uint64
+	// Removed is the number of rows whose NULL value was replaced.
+	Removed uint64
+}
+
+// countNullTransitions counts the columns of a modified row which changed to or from NULL. Added and
+// removed rows have no transitions, as there is no value on the other side to compare against.
+func (qd *QueryDiffer) countNullTransitions(from, to sql.Row) {
+	if from == nil || to == nil {
+		return
+	}
+
+	if qd.nullTransitions == nil {
+		qd.nullTransitions = make([]NullTransition, len(qd.sch))
+	}
+
+	for i := range qd.nullTransitions {
+		if i >= len(from) || i >= len(to) {
+			break
+		}
+
+		fromNull, toNull := from[i] == nil, to[i] == nil
+		if fromNull && !toNull {
+			qd.nullTransitions[i].Removed++
+		} else if !fromNull && toNull {
+			qd.nullTransitions[i].Added++
+		}
+	}
+}
+
+// NullTransitions returns the number of modified rows in which each column changed to or from NULL,
+// among the diffs that have been read so far. Only columns with at least one transition are
+// included, keyed by column name. Data quality tools can use it to find the columns which gained
+// or lost NULLs between the roots. The counts are cleared by Reset.
+func (qd *QueryDiffer) NullTransitions() map[string]NullTransition {
+	transitions := make(map[string]NullTransition)
+	for i, nt := range qd.nullTransitions {
+		if nt.Added > 0 || nt.Removed > 0 {
+			transitions[qd.sch[i].Name] = nt
+		}
+	}
+	return transitions
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import "strings"
+
+// QueryDifferOption configures optional behavior of a QueryDiffer created by MakeQueryDiffer.
+type QueryDifferOption func(opts *queryDifferOpts)
+
+type queryDifferOpts struct {
+	columns        []string
+	normalize      map[string]StringNormalization
+	floatTolerance map[string]float64
+	keyColumns     []string
+	columnMapping  map[string]string
+	spillThreshold int
+}
+
+// WithColumns restricts a QueryDiffer to the named columns of the query results. Only these
+// columns are compared when looking for changed rows, and only these columns are included in
+// the rows it emits. Each column must exist in the results of the query on both roots.
+func WithColumns(columns ...string) QueryDifferOption {
+	return func(opts *queryDifferOpts) {
+		opts.columns = columns
+	}
+}
+
+// WithKeyColumns matches the rows of each root by the values of the named columns, rather than by
+// the order of the query results. A row whose other columns change is then reported as modified,
+// rather than as removed and added, even when the change moves it within the query results. The
+// rows are diffed in ascending key order instead of the order of the query. The columns must exist
+// in the input of the query's sort node, and identify a single row of each root's results; NULL or
+// duplicate keys cause an error. Key columns cannot be used with a query which has a LIMIT.
+func WithKeyColumns(columns ...string) QueryDifferOption {
+	return func(opts *queryDifferOpts) {
+		opts.keyColumns = columns
+	}
+}
+
+// WithSpillThreshold bounds the memory used to sort the rows of each root before they are diffed.
+// Without it, the rows of each root's sort node are buffered and sorted in memory, including those
+// of DISTINCT and GROUP BY ... HAVING queries without an ORDER BY, whose unordered results are
+// sorted so they can be diffed as sets. Once the buffered rows of a root are estimated to take more
+// than |bytes| bytes, they are sorted and written to a temporary file, and the sorted files are
+// merged as rows are diffed. Results which fit within the threshold are sorted in memory as before.
+func WithSpillThreshold(bytes int) QueryDifferOption {
+	return func(opts *queryDifferOpts) {
+		opts.spillThreshold = bytes
+	}
+}
+
+// WithColumnMapping aligns the columns of the to query results with the columns of the from query
+// results before rows are compared, so that renamed or reordered columns are not reported as diffs.
+// |mapping| maps the names of renamed columns of the to results to their names in the from results;
+// columns which are not in |mapping| are matched by name. The to results are reordered to match the
+// from results, and the diffed rows have the columns of the from results. Each mapped column must
+// exist in its results, and both results must have the same number of columns. The query itself
+// must run on both roots, so it should not refer to renamed columns by name, e.g. "select * ...".
+func WithColumnMapping(mapping map[string]string) QueryDifferOption {
+	return func(opts *queryDifferOpts) {
+		opts.columnMapping = mapping
+	}
+}
+
+// StringNormalization describes how the string values of a column are normalized before they are
+// compared. Rows whose values differ only by characters that are normalized away are treated as equal.
+type StringNormalization struct {
+	// TrimSpace removes leading and trailing whitespace.
+	TrimSpace bool
+	// Lowercase converts the value to lower case.
+	Lowercase bool
+}
+
+func (sn StringNormalization) apply(s string) string {
+	if sn.TrimSpace {
+		s = strings.TrimSpace(s)
+	}
+	if sn.Lowercase {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// WithStringNormalization normalizes the string values of the named column before rows are ordered
+// and compared. The normalized values are only used to order and compare the rows of both roots, so
+// the rows a QueryDiffer emits contain the values of each root as they are. The column is matched by
+// name, ignoring case, against the columns of the query's sort node and must exist in both roots.
+func WithStringNormalization(column string, norm StringNormalization) QueryDifferOption {
+	return func(opts *queryDifferOpts) {
+		if opts.normalize == nil {
+			opts.normalize = make(map[string]StringNormalization)
+		}
+		opts.normalize[strings.ToLower(column)] = norm
+	}
+}
+
+// WithFloatTolerance treats the float values of the named column as equal when they round to the
+// same multiple of |epsilon|, so values that differ only by rounding in their last digits are not
+// reported as changed. The rounded values are used both to order the rows of each root and to
+// compare matched rows, so equality within the tolerance is transitive and the rows of both roots
+// are merged in a consistent order. Values closer together than |epsilon| which round to adjacent
+// multiples of it, such as 0.0049 and 0.0051 with an |epsilon| of 0.01, are not equal. The column is
+// matched by name, ignoring case, and must exist in the query results.
+func WithFloatTolerance(column string, epsilon float64) QueryDifferOption {
+	return func(opts *queryDifferOpts) {
+		if opts.floatTolerance == nil {
+			opts.floatTolerance = make(map[string]float64)
+		}
+		opts.floatTolerance[strings.ToLower(column)] = epsilon
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+const defaultProgressInterval = 1000
+
+// ProgressCallback is called with the number of rows a QueryDiffer has read from the results of both
+// roots and the number of diffs it has found so far.
+type ProgressCallback func(rowsRead, diffsFound uint64)
+
+type progressTracker struct {
+	cb         ProgressCallback
+	interval   uint64
+	rowsRead   uint64
+	diffsFound uint64
+	nextReport uint64
+	finished   bool
+}
+
+func newProgressTracker(cb ProgressCallback, interval uint64) *progressTracker {
+	p := &progressTracker{cb: cb}
+	p.setInterval(interval)
+	return p
+}
+
+func (p *progressTracker) setInterval(interval uint64) {
+	if interval == 0 {
+		interval = 1
+	}
+	p.interval = interval
+	p.nextReport = p.rowsRead + interval
+}
+
+func (p *progressTracker) rowRead() {
+	p.rowsRead++
+	if p.rowsRead >= p.nextReport {
+		p.cb(p.rowsRead, p.diffsFound)
+		p.nextReport = p.rowsRead + p.interval
+	}
+}
+
+func (p *progressTracker) diffFound() {
+	p.diffsFound++
+}
+
+// finish reports the final counts once all diffs have been found.
+func (p *progressTracker) finish() {
+	if !p.finished {
+		p.finished = true
+		p.cb(p.rowsRead, p.diffsFound)
+	}
+}
+
+func (p *progressTracker) reset() {
+	p.rowsRead, p.diffsFound, p.finished = 0, 0, false
+	p.nextReport = p.interval
+}
+
+// SetProgressCallback sets a callback which is called as NextDiff reads the query results of both
+// roots, every time the number of rows read grows by the progress interval, and once more when all
+// diffs have been found. The interval is 1000 rows unless set with SetProgressInterval. The callback
+// is called from NextDiff, so it should return quickly. Passing nil removes the callback.
+func (qd *QueryDiffer) SetProgressCallback(cb ProgressCallback) {
+	if cb == nil {
+		qd.progress = nil
+		return
+	}
+
+	interval := uint64(defaultProgressInterval)
+	if qd.progress != nil {
+		interval = qd.progress.interval
+	}
+	qd.progress = newProgressTracker(cb, interval)
+}
+
+// SetProgressInterval sets the number of rows read between calls to the progress callback. It has
+// no effect if no progress callback is set.
+func (qd *QueryDiffer) SetProgressInterval(rows uint64) {
+	if qd.progress != nil {
+		qd.progress.setInterval(rows)
+	}
+}
@@ -19,10 +19,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 
 	sqle "github.com/liquidata-inc/go-mysql-server"
 	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
 	"github.com/liquidata-inc/go-mysql-server/sql/parse"
 	"github.com/liquidata-inc/go-mysql-server/sql/plan"
 
@@ -34,13 +36,51 @@ import (
 
 var errSkip = errors.New("errSkip") // u lyk hax?
 
+var errCannotReset = errors.New("cannot reset a QueryDiffer created from row iterators")
+
 type QueryDiffer struct {
 	sch      sql.Schema
+	fromCtx  *sql.Context
+	toCtx    *sql.Context
+	fromPlan sql.Node
+	toPlan   sql.Node
+	nd       nodeDiffer
 	fromIter sql.RowIter
 	toIter   sql.RowIter
+	progress *progressTracker
+	// emitUnchanged makes NextDiff return rows which are equal in both roots
+	emitUnchanged bool
+	// tolerances holds the float tolerance of each column of sch, or nil if there are none
+	tolerances []float64
+	// normalizations holds the string normalization of each column of sch, or nil if there are none
+	normalizations []StringNormalization
+	// nullTransitions counts the changes to and from NULL of each column of sch
+	nullTransitions []NullTransition
+	// peeked is a diff that NextPage read ahead of the caller, to be returned before any other rows
+	peeked *RowDiff
+	// keyColumns are the key columns the rows of each root are paired up by, if any
+	keyColumns []string
+	// groups builds the GroupDiffs returned by NextGroupDiff
+	groups *groupMatcher
+	// identical is set when the roots are identical, in which case the query is only run to emit
+	// unchanged rows
+	identical bool
 }
 
-func MakeQueryDiffer(ctx context.Context, dEnv *env.DoltEnv, fromRoot, toRoot *doltdb.RootValue, query string) (*QueryDiffer, error) {
+// MakeQueryDiffer creates a QueryDiffer which diffs the results of |query| on |fromRoot| and |toRoot|.
+// If the roots are identical the query plans are built and validated as for any other roots, but
+// the query is not run unless SetEmitUnchanged(true) is called: the QueryDiffer returns no diffs.
+func MakeQueryDiffer(ctx context.Context, dEnv *env.DoltEnv, fromRoot, toRoot *doltdb.RootValue, query string, opts ...QueryDifferOption) (*QueryDiffer, error) {
+	var qdOpts queryDifferOpts
+	for _, opt := range opts {
+		opt(&qdOpts)
+	}
+
+	identical, err := rootsAreIdentical(fromRoot, toRoot)
+	if err != nil {
+		return nil, err
+	}
+
 	fromCtx, fromEng, err := makeSqlEngine(ctx, dEnv, fromRoot)
 	if err != nil {
 		return nil, err
@@ -50,11 +90,39 @@ func MakeQueryDiffer(ctx context.Context, dEnv *env.DoltEnv, fromRoot, toRoot *d
 		return nil, err
 	}
 
-	from, to, err := modifyQueryPlans(fromCtx, toCtx, fromEng, toEng, query)
+	from, to, nd, err := modifyQueryPlans(fromCtx, toCtx, fromEng, toEng, query, qdOpts)
 	if err != nil {
 		return nil, err
 	}
 
+	if qdOpts.columnMapping != nil {
+		to, err = alignColumns(from.Schema(), to, qdOpts.columnMapping)
+		if err != nil {
+			return nil, fmt.Errorf("error mapping columns of query results: %s", err.Error())
+		}
+	}
+
+	if qdOpts.columns != nil {
+		from, err = projectColumns(from, qdOpts.columns)
+		if err != nil {
+			return nil, fmt.Errorf("error projecting from query results: %s", err.Error())
+		}
+		to, err = projectColumns(to, qdOpts.columns)
+		if err != nil {
+			return nil, fmt.Errorf("error projecting to query results: %s", err.Error())
+		}
+	}
+
+	err = validateColumnTypes(from.Schema(), to.Schema())
+	if err != nil {
+		return nil, fmt.Errorf("cannot diff query: %s", err.Error())
+	}
+
+	tolerances, err := columnTolerances(from.Schema(), qdOpts.floatTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("error applying float tolerance: %s", err.Error())
+	}
+
 	fromIter, err := from.RowIter(fromCtx)
 	if err != nil {
 		return nil, err
@@ -70,41 +138,186 @@ func MakeQueryDiffer(ctx context.Context, dEnv *env.DoltEnv, fromRoot, toRoot *d
 
 	qd := &QueryDiffer{
 		sch:      from.Schema(),
+		fromCtx:  fromCtx,
+		toCtx:    toCtx,
+		fromPlan: from,
+		toPlan:   to,
+		nd:       nd,
 		fromIter: fromIter,
 		toIter:   toIter,
+
+		tolerances:     tolerances,
+		normalizations: columnNormalizations(from.Schema(), qdOpts.normalize),
+		keyColumns:     qdOpts.keyColumns,
+		identical:      identical,
 	}
 
 	return qd, nil
 }
 
+// NewQueryDifferFromIters creates a QueryDiffer which diffs the rows of |from| and |to| rather than
+// the results of a query. Both iterators must produce rows with schema |sch|, sorted according to
+// |sortFields|. A QueryDiffer created this way cannot be Reset.
+func NewQueryDifferFromIters(sch sql.Schema, sortFields []plan.SortField, from, to sql.RowIter) *QueryDiffer {
+	ctx := sql.NewContext(context.Background())
+	nd := newIterNodeDiffer(ctx, sch, sortFields, from, to)
+
+	return &QueryDiffer{
+		sch:      sch,
+		fromCtx:  ctx,
+		toCtx:    ctx,
+		nd:       nd,
+		fromIter: nd.fromRowIter(),
+		toIter:   nd.toRowIter(),
+	}
+}
+
+// SetEmitUnchanged sets whether NextDiff, NextRowDiff and Stream also return the rows which are equal
+// in both roots, so that the full query results can be rendered with their diffs in context. An
+// unchanged row is returned as both the from and to row, and NextRowDiff classifies it as Unchanged.
+// The query is run even if the roots are identical, in which case every row is returned as unchanged.
+func (qd *QueryDiffer) SetEmitUnchanged(b bool) {
+	qd.emitUnchanged = b
+}
+
 func (qd *QueryDiffer) NextDiff() (from sql.Row, to sql.Row, err error) {
+	from, to, _, err = qd.next()
+	return from, to, err
+}
+
+// next returns the next pair of rows, and whether they are unchanged. Unchanged rows are only
+// returned if emitUnchanged is set.
+func (qd *QueryDiffer) next() (from sql.Row, to sql.Row, unchanged bool, err error) {
+	if qd.peeked != nil {
+		rd := qd.peeked
+		qd.peeked = nil
+		return rd.From, rd.To, rd.Type == Unchanged, nil
+	}
+
+	if qd.identical && !qd.emitUnchanged {
+		// the diff of identical roots is empty, so there is no need to read the results of either root
+		if qd.progress != nil {
+			qd.progress.finish()
+		}
+		return nil, nil, false, io.EOF
+	}
+
 	var fromEOF bool
 	for {
 		from, err = qd.fromIter.Next()
 		if err == io.EOF {
 			fromEOF = true
 		} else if err != nil && err != errSkip {
-			return nil, nil, err
+			return nil, nil, false, err
 		}
 
 		to, err = qd.toIter.Next()
 		if err != nil && err != errSkip && err != io.EOF {
-			return nil, nil, err
+			return nil, nil, false, err
 		}
 
 		if fromEOF && err == io.EOF {
-			return nil, nil, io.EOF
+			if qd.progress != nil {
+				qd.progress.finish()
+			}
+			return nil, nil, false, io.EOF
 		}
 
-		eq, err := from.Equals(to, qd.sch)
+		if qd.progress != nil {
+			if from != nil {
+				qd.progress.rowRead()
+			}
+			if to != nil {
+				qd.progress.rowRead()
+			}
+		}
+
+		// rowsEqual compares each column with its sql.Type, which treats two NULLs as
+		// equal, so rows that differ only by shared NULLs are not reported as diffs.
+		eq, err := rowsEqual(qd.sch, qd.tolerances, qd.normalizations, from, to)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, false, err
 		}
 		if eq {
+			if qd.emitUnchanged {
+				return from, to, true, nil
+			}
 			continue
 		}
 
-		return from, to, nil
+		if qd.progress != nil {
+			qd.progress.diffFound()
+		}
+		qd.countNullTransitions(from, to)
+		return from, to, false, nil
+	}
+}
+
+// HasDiff returns whether the results of the query differ between the roots. Rows are only read until the first diff
+// is found, rather than through the end of the results, and the QueryDiffer is closed before HasDiff returns. Reset
+// the QueryDiffer to read its diffs afterward.
+func (qd *QueryDiffer) HasDiff() (bool, error) {
+	found, err := qd.firstDiff()
+	closeErr := qd.Close()
+	if err != nil {
+		return false, err
+	}
+	if closeErr != nil {
+		return false, closeErr
+	}
+	return found, nil
+}
+
+// firstDiff reads rows until it finds a diff, skipping any unchanged rows.
+func (qd *QueryDiffer) firstDiff() (bool, error) {
+	for {
+		_, _, unchanged, err := qd.next()
+		if err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if !unchanged {
+			return true, nil
+		}
+	}
+}
+
+// CountOnly reads the remaining results of the query and returns the number of rows added, removed and modified
+// between the roots, without building a RowDiff for each of them. Unchanged rows are not counted, even if
+// SetEmitUnchanged(true) was called. The QueryDiffer is closed before CountOnly returns.
+func (qd *QueryDiffer) CountOnly() (added, removed, modified uint64, err error) {
+	added, removed, modified, err = qd.countDiffs()
+	closeErr := qd.Close()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if closeErr != nil {
+		return 0, 0, 0, closeErr
+	}
+	return added, removed, modified, nil
+}
+
+// countDiffs reads rows through the end of the results, tallying each diff by its classification.
+func (qd *QueryDiffer) countDiffs() (added, removed, modified uint64, err error) {
+	for {
+		from, to, unchanged, err := qd.next()
+		if err == io.EOF {
+			return added, removed, modified, nil
+		} else if err != nil {
+			return 0, 0, 0, err
+		}
+
+		switch {
+		case unchanged:
+			// unchanged rows are only returned with SetEmitUnchanged(true), and are not diffs
+		case from == nil:
+			added++
+		case to == nil:
+			removed++
+		default:
+			modified++
+		}
 	}
 }
 
@@ -112,6 +325,21 @@ func (qd *QueryDiffer) Schema() sql.Schema {
 	return qd.sch
 }
 
+// DiffPlanString renders the modified query plans for both roots. The point at which each plan was
+// rewritten to feed diffed rows to its parent nodes is shown as a QueryDiff node.
+func (qd *QueryDiffer) DiffPlanString() string {
+	if qd.fromPlan == nil {
+		return "diff of row iterators: no query plans"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("from plan:\n")
+	sb.WriteString(qd.fromPlan.String())
+	sb.WriteString("\nto plan:\n")
+	sb.WriteString(qd.toPlan.String())
+	return sb.String()
+}
+
 func (qd *QueryDiffer) Close() error {
 	fromErr := qd.fromIter.Close()
 	toErr := qd.toIter.Close()
@@ -121,41 +349,86 @@ func (qd *QueryDiffer) Close() error {
 	return toErr
 }
 
-func modifyQueryPlans(fromCtx *sql.Context, toCtx *sql.Context, fromEng *sqle.Engine, toEng *sqle.Engine, query string) (fromPlan, toPlan sql.Node, err error) {
+// Reset closes the current diff iteration and restarts it from the beginning. The modified query plans
+// are reused, so the sql engines for each root are not rebuilt. A QueryDiffer created with
+// NewQueryDifferFromIters cannot be reset.
+func (qd *QueryDiffer) Reset() error {
+	if qd.fromPlan == nil {
+		return errCannotReset
+	}
+
+	err := qd.Close()
+	if err != nil {
+		return err
+	}
+
+	err = qd.nd.reset()
+	if err != nil {
+		return err
+	}
+
+	qd.fromIter, err = qd.fromPlan.RowIter(qd.fromCtx)
+	if err != nil {
+		return err
+	}
+	qd.toIter, err = qd.toPlan.RowIter(qd.toCtx)
+	if err != nil {
+		return err
+	}
+
+	if qd.progress != nil {
+		qd.progress.reset()
+	}
+	qd.nullTransitions = nil
+	qd.peeked = nil
+
+	return nil
+}
+
+func modifyQueryPlans(fromCtx *sql.Context, toCtx *sql.Context, fromEng *sqle.Engine, toEng *sqle.Engine, query string, opts queryDifferOpts) (fromPlan, toPlan sql.Node, nd nodeDiffer, err error) {
 	parsed, err := parse.Parse(fromCtx, query)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	fromPlan, err = fromEng.Analyzer.Analyze(fromCtx, parsed)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error executing query on from root: %s", err.Error())
+		return nil, nil, nil, fmt.Errorf("error executing query on from root: %s", err.Error())
 	}
 	err = recursiveValidateQueryPlan(fromPlan)
 	if err != nil {
-		return nil, nil, errWithQueryPlan(fromCtx, fromEng, query, err)
+		return nil, nil, nil, errWithQueryPlan(fromCtx, fromEng, query, err)
 	}
 
 	toPlan, err = toEng.Analyzer.Analyze(toCtx, parsed)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error executing query on to root: %s", err.Error())
+		return nil, nil, nil, fmt.Errorf("error executing query on to root: %s", err.Error())
 	}
 	err = recursiveValidateQueryPlan(toPlan)
 	if err != nil {
-		return nil, nil, errWithQueryPlan(toCtx, toEng, query, err)
+		return nil, nil, nil, errWithQueryPlan(toCtx, toEng, query, err)
 	}
 
-	fromPlan, toPlan, err = recursiveModifyQueryPlans(fromCtx, toCtx, fromPlan, toPlan)
+	err = validatePlanShapes(fromPlan, toPlan)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, fmt.Errorf("cannot diff query: %s", err.Error())
 	}
 
-	return fromPlan, toPlan, nil
+	fromPlan, toPlan, nd, err = recursiveModifyQueryPlans(fromCtx, toCtx, fromPlan, toPlan, opts, noLimit)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return fromPlan, toPlan, nd, nil
 }
 
 func recursiveValidateQueryPlan(p sql.Node) error {
+	if isGroupFilter(p) {
+		return nil
+	}
+
 	switch p.(type) {
-	case *plan.Sort:
+	case *plan.Sort, *plan.Distinct, *plan.OrderedDistinct:
 		return nil
 	default:
 		cc := p.Children()
@@ -166,34 +439,172 @@ func recursiveValidateQueryPlan(p sql.Node) error {
 	}
 }
 
-func recursiveModifyQueryPlans(fromCtx, toCtx *sql.Context, from, to sql.Node) (modFrom, modTo sql.Node, err error) {
+// validatePlanShapes checks that the from and to plans have the same nodes along the path that
+// recursiveModifyQueryPlans walks down to the sort node, and that both sort nodes have the same
+// number of sort fields. The analyzer may build different plans for each root, and the plans
+// cannot be diffed when they diverge above the sort node.
+func validatePlanShapes(from, to sql.Node) error {
+	if reflect.TypeOf(from) != reflect.TypeOf(to) {
+		return fmt.Errorf("query plans of the from and to roots differ: from plan has %T node where to plan has %T node", from, to)
+	}
+
+	if fromSort, ok := from.(*plan.Sort); ok {
+		toSort := to.(*plan.Sort)
+		if len(fromSort.SortFields) != len(toSort.SortFields) {
+			return fmt.Errorf("query plans of the from and to roots differ: from plan sorts by %d fields where to plan sorts by %d fields", len(fromSort.SortFields), len(toSort.SortFields))
+		}
+		return nil
+	}
+
+	if isDistinct(from) {
+		if _, ok := from.Children()[0].(*plan.Sort); !ok {
+			// the rows of both distinct nodes are sorted by all of their columns
+			return nil
+		}
+	}
+
+	if isGroupFilter(from) && isGroupFilter(to) {
+		// the rows of both group filters are sorted by all of their columns
+		return nil
+	}
+
+	fc, tc := from.Children(), to.Children()
+	if len(fc) != len(tc) {
+		return fmt.Errorf("query plans of the from and to roots differ: %T node has %d children in from plan and %d children in to plan", from, len(fc), len(tc))
+	}
+	if len(fc) == 0 {
+		return fmt.Errorf("query plan does not contain a sort node")
+	}
+
+	return validatePlanShapes(fc[0], tc[0])
+}
+
+// recursiveModifyQueryPlans injects a nodeDiffer at the sort node of |from| and |to|. A Limit node
+// which limits the rows of the sort node, such as that of an ORDER BY ... LIMIT n query, is
+// removed from the plans and pushed down into the nodeDiffer, so that the first n rows of each
+// root's results are diffed, rather than the diff of all rows being cut off after n diffs. The sort
+// nodes still read and sort all of their input. A Distinct node is replaced by the nodeDiffer, which removes duplicate rows from the results of the
+// sort node returned by distinctSort; deduplicating the results of the nodeDiffer instead would skip
+// the rows of one root but not the other. The group filter of a GROUP BY ... HAVING query without
+// an ORDER BY clause is given a sort node above the filter, by sortByAllColumns, so that the groups
+// which pass the filter are diffed. Both plans must sort their rows in the same order, which
+// validatePlanShapes checks.
+func recursiveModifyQueryPlans(fromCtx, toCtx *sql.Context, from, to sql.Node, opts queryDifferOpts, limit int64) (modFrom, modTo sql.Node, nd nodeDiffer, err error) {
+	if fromLimit, ok := from.(*plan.Limit); ok && limitsSortNode(fromLimit) {
+		if len(opts.keyColumns) > 0 {
+			return nil, nil, nil, fmt.Errorf("key columns cannot be used with a LIMIT query, as rows are diffed in key order")
+		}
+		toLimit := to.(*plan.Limit)
+		if fromLimit.Limit == toLimit.Limit {
+			return recursiveModifyQueryPlans(fromCtx, toCtx, fromLimit.Child, toLimit.Child, opts, fromLimit.Limit)
+		}
+	}
+
+	distinct := isDistinct(from)
+	if distinct {
+		from, to = distinctSort(from), distinctSort(to)
+	} else if isGroupFilter(from) && isGroupFilter(to) {
+		from, to = sortByAllColumns(from), sortByAllColumns(to)
+	}
+
 	switch from.(type) {
 	case *plan.Sort:
-		nd, err := newSortNodeDiffer(fromCtx, toCtx, from.(*plan.Sort), to.(*plan.Sort))
+		fromSort, toSort := from.(*plan.Sort), to.(*plan.Sort)
+		if len(opts.keyColumns) > 0 {
+			fromSort, err = sortByKey(fromSort, opts.keyColumns)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error keying from query results: %s", err.Error())
+			}
+			toSort, err = sortByKey(toSort, opts.keyColumns)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error keying to query results: %s", err.Error())
+			}
+		}
+		if len(opts.normalize) > 0 {
+			fromSort, err = normalizeSort(fromSort, opts.normalize)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error normalizing from query results: %s", err.Error())
+			}
+			toSort, err = normalizeSort(toSort, opts.normalize)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error normalizing to query results: %s", err.Error())
+			}
+		}
+		if len(opts.floatTolerance) > 0 {
+			fromSort, err = quantizeSort(fromSort, opts.floatTolerance)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error quantizing from query results: %s", err.Error())
+			}
+			toSort, err = quantizeSort(toSort, opts.floatTolerance)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error quantizing to query results: %s", err.Error())
+			}
+		}
+		nd, err = newSortNodeDiffer(fromCtx, toCtx, fromSort, toSort, opts, limit, distinct)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		modFrom, modTo = nd.makeFromNode(), nd.makeToNode()
 	default:
 		fc := from.Children()
 		tc := to.Children()
 		if fc == nil || tc == nil {
-			panic("query plan does not contain a sort node")
+			return nil, nil, nil, fmt.Errorf("query plan does not contain a sort node")
 		}
-		fc[0], tc[0], err = recursiveModifyQueryPlans(fromCtx, toCtx, fc[0], tc[0])
+		fc[0], tc[0], nd, err = recursiveModifyQueryPlans(fromCtx, toCtx, fc[0], tc[0], opts, limit)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		modFrom, err = from.WithChildren(fc...)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 		modTo, err = to.WithChildren(tc...)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
+		}
+	}
+	return modFrom, modTo, nd, nil
+}
+
+// projectColumns wraps |p| in a Project node which selects |columns| from its results.
+func projectColumns(p sql.Node, columns []string) (sql.Node, error) {
+	sch := p.Schema()
+	exprs := make([]sql.Expression, len(columns))
+	for i, name := range columns {
+		idx := columnIndex(sch, name)
+		if idx < 0 {
+			return nil, fmt.Errorf("column '%s' not found", name)
+		}
+		col := sch[idx]
+		exprs[i] = expression.NewGetField(idx, col.Type, col.Name, col.Nullable)
+	}
+	return plan.NewProject(exprs, p), nil
+}
+
+// rootsAreIdentical returns whether |fromRoot| and |toRoot| have the same hash, in which case
+// every query has the same results on both roots.
+func rootsAreIdentical(fromRoot, toRoot *doltdb.RootValue) (bool, error) {
+	fromHash, err := fromRoot.HashOf()
+	if err != nil {
+		return false, err
+	}
+	toHash, err := toRoot.HashOf()
+	if err != nil {
+		return false, err
+	}
+	return fromHash == toHash, nil
+}
+
+// columnIndex returns the index of the column of |sch| named |name|, ignoring case, or -1 if
+// there is no such column.
+func columnIndex(sch sql.Schema, name string) int {
+	for i, col := range sch {
+		if strings.EqualFold(col.Name, name) {
+			return i
 		}
 	}
-	return modFrom, modTo, nil
+	return -1
 }
 
 func makeSqlEngine(ctx context.Context, dEnv *env.DoltEnv, root *doltdb.RootValue) (*sql.Context, *sqle.Engine, error) {
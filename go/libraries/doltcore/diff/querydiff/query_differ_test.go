@@ -15,18 +15,27 @@
 package querydiff_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff/querydiff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 )
 
 type queryDifferTest struct {
@@ -110,6 +119,213 @@ var queryDifferTests = []queryDifferTest{
 			{from: nil, to: sql.Row{int32(9)}},
 		},
 	},
+	{
+		name:  "descending order",
+		query: "select * from test order by pk desc",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 1"}},
+			{commands.SqlCmd{}, []string{"-q", "update test set c0 = 20 where pk = 2"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (9,9), (-1,-1)"}},
+		},
+		diffRows: []diffRow{
+			{from: nil, to: sql.Row{int32(9), int32(9)}},
+			{from: sql.Row{int32(2), int32(2)}, to: sql.Row{int32(2), int32(20)}},
+			{from: sql.Row{int32(1), int32(1)}, to: nil},
+			{from: nil, to: sql.Row{int32(-1), int32(-1)}},
+		},
+	},
+	{
+		name:  "descending order, interleaved",
+		query: "select * from test order by pk desc",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "delete from test where pk in (0, 2)"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (4,4), (-2,-2)"}},
+		},
+		diffRows: []diffRow{
+			{from: nil, to: sql.Row{int32(4), int32(4)}},
+			{from: sql.Row{int32(2), int32(2)}, to: nil},
+			{from: sql.Row{int32(0), int32(0)}, to: nil},
+			{from: nil, to: sql.Row{int32(-2), int32(-2)}},
+		},
+	},
+	{
+		name:  "descending order, from rows exhausted first",
+		query: "select * from test order by pk desc",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (-1,-1), (-2,-2)"}},
+		},
+		diffRows: []diffRow{
+			{from: nil, to: sql.Row{int32(-1), int32(-1)}},
+			{from: nil, to: sql.Row{int32(-2), int32(-2)}},
+		},
+	},
+	{
+		name:  "descending order with limit",
+		query: "select * from test order by pk desc limit 2",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (4,4)"}},
+			{commands.SqlCmd{}, []string{"-q", "update test set c0 = 20 where pk = 0"}},
+		},
+		// the first 2 rows of each root are diffed, so the row with pk 2 falls out of the to
+		// results and the update to the row with pk 0 is not seen
+		diffRows: []diffRow{
+			{from: nil, to: sql.Row{int32(4), int32(4)}},
+			{from: sql.Row{int32(2), int32(2)}, to: nil},
+		},
+	},
+	{
+		name:  "null in both roots",
+		query: "select * from test order by pk",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (4,NULL)"}},
+			{commands.AddCmd{}, []string{"."}},
+			{commands.CommitCmd{}, []string{"-m", "null row"}},
+			{commands.SqlCmd{}, []string{"-q", "update test set c0 = 10 where pk = 1"}},
+		},
+		diffRows: []diffRow{
+			{from: sql.Row{int32(1), int32(1)}, to: sql.Row{int32(1), int32(10)}},
+		},
+	},
+	{
+		name:  "null sort key in both roots",
+		query: "select * from test order by c0, pk",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (4,NULL), (5,NULL)"}},
+			{commands.AddCmd{}, []string{"."}},
+			{commands.CommitCmd{}, []string{"-m", "null rows"}},
+			{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 5"}},
+		},
+		diffRows: []diffRow{
+			{from: sql.Row{int32(5), nil}, to: nil},
+		},
+	},
+	{
+		name:  "order by expression",
+		query: "select * from names order by lower(name), pk",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "create table names (pk int not null primary key, name varchar(20))"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into names values (0,'Bob'), (1,'alice'), (2,'Carol')"}},
+			{commands.AddCmd{}, []string{"."}},
+			{commands.CommitCmd{}, []string{"-m", "names"}},
+			{commands.SqlCmd{}, []string{"-q", "update names set name = 'Dave' where pk = 1"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into names values (3,'aaron')"}},
+		},
+		diffRows: []diffRow{
+			{from: nil, to: sql.Row{int32(3), "aaron"}},
+			{from: sql.Row{int32(1), "alice"}, to: nil},
+			{from: nil, to: sql.Row{int32(1), "Dave"}},
+		},
+	},
+	{
+		name:  "sort column at different index in each root",
+		query: "select pk, c0 from test order by c0",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "drop table test"}},
+			{commands.SqlCmd{}, []string{"-q", "create table test (c0 int, pk int not null primary key)"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (0,0), (1,1), (30,2), (3,3)"}},
+		},
+		diffRows: []diffRow{
+			{from: sql.Row{int32(2), int32(2)}, to: nil},
+			{from: nil, to: sql.Row{int32(2), int32(30)}},
+		},
+	},
+	{
+		name:  "tied rows stored in different order",
+		query: "select * from ties order by c",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "create table ties (a int not null primary key, b int, c int)"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into ties values (1,3,0), (2,2,0), (3,1,0), (4,4,1)"}},
+			{commands.AddCmd{}, []string{"."}},
+			{commands.CommitCmd{}, []string{"-m", "ties"}},
+			{commands.SqlCmd{}, []string{"-q", "drop table ties"}},
+			{commands.SqlCmd{}, []string{"-q", "create table ties (a int, b int not null primary key, c int)"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into ties values (1,3,0), (2,2,0), (3,1,0), (4,4,1)"}},
+		},
+		diffRows: []diffRow{},
+	},
+	{
+		name:  "select distinct",
+		query: "select distinct c0 from test",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 2"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (4,1), (5,9), (6,9)"}},
+		},
+		// distinct results are diffed as sets, in ascending order, so the duplicate
+		// values in the to root are not diffs
+		diffRows: []diffRow{
+			{from: sql.Row{int32(2)}, to: nil},
+			{from: nil, to: sql.Row{int32(9)}},
+		},
+	},
+	{
+		name:  "select distinct with order by",
+		query: "select distinct c0 from test order by c0 desc",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 2"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (4,1), (5,9), (6,9)"}},
+		},
+		diffRows: []diffRow{
+			{from: nil, to: sql.Row{int32(9)}},
+			{from: sql.Row{int32(2)}, to: nil},
+		},
+	},
+	{
+		name:  "group by with having",
+		query: "select c0, sum(pk) as total from test group by c0 having sum(pk) > 2",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 3"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (4,1), (5,1), (6,2)"}},
+		},
+		// the groups which pass the filter are diffed as sets, in ascending order
+		diffRows: []diffRow{
+			{from: nil, to: sql.Row{int32(1), float64(10)}},
+			{from: nil, to: sql.Row{int32(2), float64(8)}},
+			{from: sql.Row{int32(3), float64(3)}, to: nil},
+		},
+	},
+	{
+		name:  "group by with having and order by",
+		query: "select c0, sum(pk) as total from test group by c0 having sum(pk) > 2 order by c0 desc",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 3"}},
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (4,1), (5,1), (6,2)"}},
+		},
+		diffRows: []diffRow{
+			{from: sql.Row{int32(3), float64(3)}, to: nil},
+			{from: nil, to: sql.Row{int32(2), float64(8)}},
+			{from: nil, to: sql.Row{int32(1), float64(10)}},
+		},
+	},
+	{
+		name:  "both results empty",
+		query: "select * from test where c0 > 100 order by pk",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (9,9)"}},
+		},
+		diffRows: []diffRow{},
+	},
+	{
+		name:  "from results empty",
+		query: "select * from test where c0 > 5 order by pk",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "insert into test values (7,7), (8,8)"}},
+		},
+		diffRows: []diffRow{
+			{from: nil, to: sql.Row{int32(7), int32(7)}},
+			{from: nil, to: sql.Row{int32(8), int32(8)}},
+		},
+	},
+	{
+		name:  "to results empty",
+		query: "select * from test where c0 < 2 order by pk",
+		setup: []testCommand{
+			{commands.SqlCmd{}, []string{"-q", "delete from test where pk in (0, 1)"}},
+		},
+		diffRows: []diffRow{
+			{from: sql.Row{int32(0), int32(0)}, to: nil},
+			{from: sql.Row{int32(1), int32(1)}, to: nil},
+		},
+	},
 }
 
 func TestQueryDiffer(t *testing.T) {
@@ -121,7 +337,34 @@ func TestQueryDiffer(t *testing.T) {
 }
 
 func testQueryDiffer(t *testing.T, test queryDifferTest) {
-	dEnv := dtestutils.CreateTestEnv()
+	qd := makeTestQueryDiffer(t, test.setup, test.query)
+	testQueryDifferRows(t, qd, test.diffRows)
+}
+
+func testQueryDifferRows(t *testing.T, qd *querydiff.QueryDiffer, diffRows []diffRow) {
+	for _, expected := range diffRows {
+		from, to, err := qd.NextDiff()
+		assert.NoError(t, err)
+		assert.Equal(t, expected.from, from)
+		assert.Equal(t, expected.to, to)
+	}
+	from, to, err := qd.NextDiff()
+	assert.Nil(t, from)
+	assert.Nil(t, to)
+	assert.Equal(t, io.EOF, err)
+}
+
+func makeTestQueryDiffer(t *testing.T, setup []testCommand, query string, opts ...querydiff.QueryDifferOption) *querydiff.QueryDiffer {
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+
+	qd, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, query, opts...)
+	require.NoError(t, err)
+
+	return qd
+}
+
+func makeTestRoots(t *testing.T, setup []testCommand) (dEnv *env.DoltEnv, fromRoot, toRoot *doltdb.RootValue) {
+	dEnv = dtestutils.CreateTestEnv()
 	ctx := context.Background()
 
 	for _, c := range setupCommon {
@@ -129,27 +372,1023 @@ func testQueryDiffer(t *testing.T, test queryDifferTest) {
 		assert.Equal(t, 0, exitCode)
 	}
 
-	for _, c := range test.setup {
+	for _, c := range setup {
 		exitCode := c.cmd.Exec(ctx, c.cmd.Name(), c.args, dEnv)
 		assert.Equal(t, 0, exitCode)
 	}
 
 	fromRoot, err := dEnv.HeadRoot(ctx)
 	require.NoError(t, err)
-	toRoot, err := dEnv.WorkingRoot(ctx)
+	toRoot, err = dEnv.WorkingRoot(ctx)
 	require.NoError(t, err)
 
-	qd, err := querydiff.MakeQueryDiffer(ctx, dEnv, fromRoot, toRoot, test.query)
+	return dEnv, fromRoot, toRoot
+}
+
+func TestQueryDifferWriteCSV(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 1"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (9,9)"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = NULL where pk = 2"}},
+	}
+	qd := makeTestQueryDiffer(t, setup, "select pk, c0, concat('a,', c0) as s from test order by pk")
+
+	var buf bytes.Buffer
+	err := qd.WriteCSV(&buf)
 	require.NoError(t, err)
+	require.NoError(t, qd.Close())
 
-	for _, expected := range test.diffRows {
-		from, to, err := qd.NextDiff()
-		assert.NoError(t, err)
-		assert.Equal(t, expected.from, from)
-		assert.Equal(t, expected.to, to)
+	expected := `diff_type,pk,c0,s
+removed,1,1,"a,1"
+modified_from,2,2,"a,2"
+modified_to,2,,
+added,9,9,"a,9"
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestQueryDifferReset(t *testing.T) {
+	test := queryDifferTests[2]
+	qd := makeTestQueryDiffer(t, test.setup, test.query)
+
+	readAll := func() []diffRow {
+		var diffs []diffRow
+		for {
+			from, to, err := qd.NextDiff()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			diffs = append(diffs, diffRow{from: from, to: to})
+		}
+		return diffs
 	}
+
+	// reset after partially consuming the diff
+	_, _, err := qd.NextDiff()
+	require.NoError(t, err)
+	require.NoError(t, qd.Reset())
+	assert.Equal(t, test.diffRows, readAll())
+
+	// reset after fully consuming the diff
+	require.NoError(t, qd.Reset())
+	assert.Equal(t, test.diffRows, readAll())
+
+	// reset before reading anything
+	require.NoError(t, qd.Reset())
+	require.NoError(t, qd.Reset())
+	assert.Equal(t, test.diffRows, readAll())
+	require.NoError(t, qd.Close())
+}
+
+func TestQueryDifferChangedColumns(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 7 where pk = 1"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = NULL where pk = 2"}},
+	}
+	qd := makeTestQueryDiffer(t, setup, "select pk, c0, c0 * 0 as zero from test order by pk")
+
 	from, to, err := qd.NextDiff()
-	assert.Nil(t, from)
-	assert.Nil(t, to)
+	require.NoError(t, err)
+	changed, err := qd.ChangedColumns(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, changed)
+
+	// value -> NULL
+	from, to, err = qd.NextDiff()
+	require.NoError(t, err)
+	changed, err = qd.ChangedColumns(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, changed)
+
+	// NULL -> value
+	changed, err = qd.ChangedColumns(to, from)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, changed)
+
+	changed, err = qd.ChangedColumns(from, from)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	_, err = qd.ChangedColumns(from, sql.Row{int32(0)})
+	assert.Error(t, err)
+}
+
+func TestQueryDifferWithColumns(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 10 where pk = 1"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (9,9)"}},
+	}
+	query := "select * from test order by pk"
+
+	qd := makeTestQueryDiffer(t, setup, query, querydiff.WithColumns("c0"))
+	assert.Equal(t, 1, len(qd.Schema()))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(1)}, to: sql.Row{int32(10)}},
+		{from: nil, to: sql.Row{int32(9)}},
+	})
+
+	// changes outside of the projected columns are ignored
+	qd = makeTestQueryDiffer(t, setup, query, querydiff.WithColumns("PK"))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: nil, to: sql.Row{int32(9)}},
+	})
+
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+	_, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, query, querydiff.WithColumns("pk", "c1"))
+	assert.Error(t, err)
+}
+
+func TestQueryDifferWithColumnMapping(t *testing.T) {
+	// the c0 column of the test table is renamed to val and moved before the pk column
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "drop table test"}},
+		{commands.SqlCmd{}, []string{"-q", "create table test (val int, pk int not null primary key)"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (0,0), (1,1), (20,2), (3,3)"}},
+	}
+	query := "select * from test order by pk"
+	mapping := map[string]string{"val": "c0"}
+
+	qd := makeTestQueryDiffer(t, setup, query, querydiff.WithColumnMapping(mapping))
+	assert.Equal(t, "c0", qd.Schema()[1].Name)
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(2), int32(2)}, to: sql.Row{int32(2), int32(20)}},
+	})
+	require.NoError(t, qd.Close())
+
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+	badMappings := []map[string]string{
+		{"missing": "c0"},
+		{"val": "missing"},
+		// pk is mapped to c0, leaving no column to match the pk column of the from results
+		{"val": "c0", "pk": "c0"},
+	}
+	for _, mapping := range badMappings {
+		_, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, query, querydiff.WithColumnMapping(mapping))
+		assert.Error(t, err, "mapping %v", mapping)
+	}
+}
+
+func TestQueryDifferIncompatibleColumnTypes(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "drop table test"}},
+		{commands.SqlCmd{}, []string{"-q", "create table test (pk int not null primary key, c0 varchar(20))"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (0,'0'), (1,'1'), (2,'2'), (3,'3')"}},
+	}
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+
+	_, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, "select * from test order by pk")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "incompatible column types")
+	assert.Contains(t, err.Error(), "'c0'")
+	assert.NotContains(t, err.Error(), "'pk'")
+
+	// columns of the same kind of type, but of different sizes, are comparable
+	setup = []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "drop table test"}},
+		{commands.SqlCmd{}, []string{"-q", "create table test (pk int not null primary key, c0 bigint)"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (0,0), (1,1), (2,2), (3,3)"}},
+	}
+	qd := makeTestQueryDiffer(t, setup, "select * from test order by pk")
+	testQueryDifferRows(t, qd, nil)
+	require.NoError(t, qd.Close())
+}
+
+func TestQueryDifferWithStringNormalization(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "create table words (pk int not null primary key, w varchar(20))"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into words values (0,'apple'), (1,'banana'), (2,'cherry'), (3,'date')"}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "words"}},
+		{commands.SqlCmd{}, []string{"-q", "update words set w = ' Banana' where pk = 1"}},
+		{commands.SqlCmd{}, []string{"-q", "update words set w = 'CHERRY  ' where pk = 2"}},
+		{commands.SqlCmd{}, []string{"-q", "update words set w = 'dates' where pk = 3"}},
+	}
+	query := "select w from words order by w"
+
+	qd := makeTestQueryDiffer(t, setup, query)
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: nil, to: sql.Row{" Banana"}},
+		{from: nil, to: sql.Row{"CHERRY  "}},
+		{from: sql.Row{"banana"}, to: nil},
+		{from: sql.Row{"cherry"}, to: nil},
+		{from: sql.Row{"date"}, to: nil},
+		{from: nil, to: sql.Row{"dates"}},
+	})
+
+	norm := querydiff.StringNormalization{TrimSpace: true, Lowercase: true}
+	qd = makeTestQueryDiffer(t, setup, query, querydiff.WithStringNormalization("W", norm))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{"date"}, to: nil},
+		{from: nil, to: sql.Row{"dates"}},
+	})
+
+	// rows are ordered and compared by their normalized values, but emitted with their values as they are
+	qd = makeTestQueryDiffer(t, setup, query, querydiff.WithStringNormalization("w", querydiff.StringNormalization{TrimSpace: true}))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: nil, to: sql.Row{" Banana"}},
+		{from: nil, to: sql.Row{"CHERRY  "}},
+		{from: sql.Row{"banana"}, to: nil},
+		{from: sql.Row{"cherry"}, to: nil},
+		{from: sql.Row{"date"}, to: nil},
+		{from: nil, to: sql.Row{"dates"}},
+	})
+
+	qd = makeTestQueryDiffer(t, setup, "select pk, w from words order by pk", querydiff.WithStringNormalization("w", querydiff.StringNormalization{Lowercase: true}))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(1), "banana"}, to: sql.Row{int32(1), " Banana"}},
+		{from: sql.Row{int32(2), "cherry"}, to: sql.Row{int32(2), "CHERRY  "}},
+		{from: sql.Row{int32(3), "date"}, to: sql.Row{int32(3), "dates"}},
+	})
+
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+	_, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, query, querydiff.WithStringNormalization("x", norm))
+	assert.Error(t, err)
+
+	// rows which tie on the sort fields are paired up by their normalized values
+	tieSetup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "create table tags (pk int not null primary key, grp int, w varchar(20))"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into tags values (0,1,'b'), (1,1,'A')"}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "tags"}},
+		{commands.SqlCmd{}, []string{"-q", "update tags set w = 'B' where pk = 0"}},
+		{commands.SqlCmd{}, []string{"-q", "update tags set w = 'a' where pk = 1"}},
+	}
+	qd = makeTestQueryDiffer(t, tieSetup, "select grp, w from tags order by grp", querydiff.WithStringNormalization("w", querydiff.StringNormalization{Lowercase: true}))
+	testQueryDifferRows(t, qd, nil)
+}
+
+func TestQueryDifferWithFloatTolerance(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "create table measures (pk int not null primary key, x double)"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into measures values (0,1.0), (1,2.0), (2,3.0)"}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "measures"}},
+		{commands.SqlCmd{}, []string{"-q", "update measures set x = 1.0000001 where pk = 0"}},
+		{commands.SqlCmd{}, []string{"-q", "update measures set x = 2.001 where pk = 1"}},
+	}
+	query := "select pk, x from measures order by x"
+
+	qd := makeTestQueryDiffer(t, setup, query)
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(0), 1.0}, to: nil},
+		{from: nil, to: sql.Row{int32(0), 1.0000001}},
+		{from: sql.Row{int32(1), 2.0}, to: nil},
+		{from: nil, to: sql.Row{int32(1), 2.001}},
+	})
+
+	// 1.0000001 is just inside the tolerance and 2.001 is just outside it
+	qd = makeTestQueryDiffer(t, setup, query, querydiff.WithFloatTolerance("X", 0.000001))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(1), 2.0}, to: nil},
+		{from: nil, to: sql.Row{int32(1), 2.001}},
+	})
+
+	qd = makeTestQueryDiffer(t, setup, "select pk, x from measures order by pk", querydiff.WithFloatTolerance("x", 0.000001))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(1), 2.0}, to: sql.Row{int32(1), 2.001}},
+	})
+
+	qd = makeTestQueryDiffer(t, setup, query, querydiff.WithFloatTolerance("x", 0.01))
+	testQueryDifferRows(t, qd, nil)
+
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+	_, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, query, querydiff.WithFloatTolerance("y", 0.01))
+	assert.Error(t, err)
+}
+
+func TestQueryDifferFloatToleranceOrder(t *testing.T) {
+	// the values of pks 0-2 are each within the tolerance of the next, but not of the one after it.
+	// the values of pks 3 and 4 are equal within the tolerance, but are not in the order of y.
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "create table measures (pk int not null primary key, x double, y int)"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into measures values (0,1.0,0), (1,1.009,0), (2,1.018,0)"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into measures values (3,5.0,2), (4,5.0000002,1)"}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "measures"}},
+		{commands.SqlCmd{}, []string{"-q", "delete from measures where pk in (0, 3)"}},
+	}
+
+	// values are quantized to the nearest multiple of the tolerance, which orders the rows of both
+	// roots consistently, so each removed row is matched with no other row
+	tests := []struct {
+		query    string
+		diffRows []diffRow
+	}{
+		{"select pk, x, y from measures where pk < 3 order by x", []diffRow{{from: sql.Row{int32(0), 1.0, int32(0)}, to: nil}}},
+		{"select pk, x, y from measures where pk < 3 order by pk", []diffRow{{from: sql.Row{int32(0), 1.0, int32(0)}, to: nil}}},
+		{"select pk, x, y from measures where pk >= 3 order by x, y", []diffRow{{from: sql.Row{int32(3), 5.0, int32(2)}, to: nil}}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			qd := makeTestQueryDiffer(t, setup, test.query, querydiff.WithFloatTolerance("x", 0.01))
+			testQueryDifferRows(t, qd, test.diffRows)
+		})
+	}
+
+	// values within the tolerance which round to different multiples of it are not equal
+	setup = []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "create table measures (pk int not null primary key, x double)"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into measures values (0,0.0049)"}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "measures"}},
+		{commands.SqlCmd{}, []string{"-q", "update measures set x = 0.0051 where pk = 0"}},
+	}
+	qd := makeTestQueryDiffer(t, setup, "select pk, x from measures order by pk", querydiff.WithFloatTolerance("x", 0.01))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(0), 0.0049}, to: sql.Row{int32(0), 0.0051}},
+	})
+}
+
+func TestQueryDifferDivergentPlans(t *testing.T) {
+	// the view is redefined in the working root, so the analyzer builds a plan with a different
+	// shape above the sort node for each root
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "create view v as select pk, c0 from test order by pk"}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "view"}},
+		{commands.SqlCmd{}, []string{"-q", "drop view v"}},
+		{commands.SqlCmd{}, []string{"-q", "create view v as select * from (select pk, c0 from test order by pk) sq"}},
+	}
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+
+	assert.NotPanics(t, func() {
+		_, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, "select * from v")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "query plans of the from and to roots differ")
+	})
+}
+
+func TestQueryDifferDiffPlanString(t *testing.T) {
+	query := "select c0 from test where pk > 0 order by pk"
+	qd := makeTestQueryDiffer(t, nil, query)
+
+	planStr := qd.DiffPlanString()
+	fromIdx := strings.Index(planStr, "from plan:")
+	toIdx := strings.Index(planStr, "to plan:")
+	require.True(t, fromIdx >= 0 && toIdx > fromIdx)
+
+	fromPlan, toPlan := planStr[fromIdx:toIdx], planStr[toIdx:]
+	assert.Contains(t, fromPlan, "QueryDiff(from)")
+	assert.NotContains(t, fromPlan, "QueryDiff(to)")
+	assert.Contains(t, toPlan, "QueryDiff(to)")
+	assert.NotContains(t, toPlan, "QueryDiff(from)")
+
+	for _, p := range []string{fromPlan, toPlan} {
+		// the sort node is rendered beneath the QueryDiff node, and its parent above it
+		qdIdx := strings.Index(p, "QueryDiff(")
+		assert.True(t, strings.Index(p, "Project(") < qdIdx)
+		assert.True(t, strings.Index(p, "Sort(") > qdIdx)
+	}
+}
+
+func TestNewQueryDifferFromIters(t *testing.T) {
+	sch := sql.Schema{
+		&sql.Column{Name: "pk", Type: sql.Int64, Nullable: false},
+		&sql.Column{Name: "c0", Type: sql.Text, Nullable: true},
+	}
+	sortFields := []plan.SortField{
+		{Column: expression.NewGetField(0, sql.Int64, "pk", false), Order: plan.Ascending, NullOrdering: plan.NullsFirst},
+	}
+
+	from := sql.RowsToRowIter(
+		sql.NewRow(int64(0), "zero"),
+		sql.NewRow(int64(1), "one"),
+		sql.NewRow(int64(2), "two"),
+		sql.NewRow(int64(4), nil),
+	)
+	to := sql.RowsToRowIter(
+		sql.NewRow(int64(0), "zero"),
+		sql.NewRow(int64(2), "TWO"),
+		sql.NewRow(int64(3), "three"),
+		sql.NewRow(int64(4), nil),
+	)
+
+	qd := querydiff.NewQueryDifferFromIters(sch, sortFields, from, to)
+	assert.Equal(t, sch, qd.Schema())
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int64(1), "one"}, to: nil},
+		{from: sql.Row{int64(2), "two"}, to: sql.Row{int64(2), "TWO"}},
+		{from: nil, to: sql.Row{int64(3), "three"}},
+	})
+	assert.Error(t, qd.Reset())
+	assert.NoError(t, qd.Close())
+}
+
+func TestNewQueryDifferFromEmptyIters(t *testing.T) {
+	sch := sql.Schema{
+		&sql.Column{Name: "pk", Type: sql.Int64, Nullable: false},
+	}
+	sortFields := []plan.SortField{
+		{Column: expression.NewGetField(0, sql.Int64, "pk", false), Order: plan.Ascending, NullOrdering: plan.NullsFirst},
+	}
+	rows := func() sql.RowIter {
+		return sql.RowsToRowIter(sql.NewRow(int64(0)), sql.NewRow(int64(1)))
+	}
+
+	qd := querydiff.NewQueryDifferFromIters(sch, sortFields, sql.RowsToRowIter(), sql.RowsToRowIter())
+	testQueryDifferRows(t, qd, nil)
+	assert.NoError(t, qd.Close())
+
+	qd = querydiff.NewQueryDifferFromIters(sch, sortFields, sql.RowsToRowIter(), rows())
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: nil, to: sql.Row{int64(0)}},
+		{from: nil, to: sql.Row{int64(1)}},
+	})
+	assert.NoError(t, qd.Close())
+
+	qd = querydiff.NewQueryDifferFromIters(sch, sortFields, rows(), sql.RowsToRowIter())
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int64(0)}, to: nil},
+		{from: sql.Row{int64(1)}, to: nil},
+	})
+	assert.NoError(t, qd.Close())
+}
+
+func TestQueryDifferComparisonErrors(t *testing.T) {
+	// the c0 column is declared as an integer, but the rows have string values which cannot be compared
+	sch := sql.Schema{
+		&sql.Column{Name: "pk", Type: sql.Int64, Nullable: false},
+		&sql.Column{Name: "c0", Type: sql.Int64, Nullable: true},
+	}
+	byPk := []plan.SortField{
+		{Column: expression.NewGetField(0, sql.Int64, "pk", false), Order: plan.Ascending, NullOrdering: plan.NullsFirst},
+	}
+	byC0 := []plan.SortField{
+		{Column: expression.NewGetField(1, sql.Int64, "c0", true), Order: plan.Ascending, NullOrdering: plan.NullsFirst},
+	}
+
+	tests := []struct {
+		name       string
+		sortFields []plan.SortField
+		errMsg     string
+	}{
+		{"column comparison", byPk, "column 'c0'"},
+		{"sort field comparison", byC0, "sort field"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from := sql.RowsToRowIter(sql.NewRow(int64(0), "zero"), sql.NewRow(int64(1), "one"))
+			to := sql.RowsToRowIter(sql.NewRow(int64(0), "ZERO"), sql.NewRow(int64(1), "ONE"))
+			qd := querydiff.NewQueryDifferFromIters(sch, test.sortFields, from, to)
+
+			assert.NotPanics(t, func() {
+				_, _, err := qd.NextDiff()
+				require.Error(t, err)
+				assert.NotEqual(t, io.EOF, err)
+				assert.Contains(t, err.Error(), test.errMsg)
+			})
+			assert.NoError(t, qd.Close())
+		})
+	}
+}
+
+func TestQueryDifferStream(t *testing.T) {
+	test := queryDifferTests[2]
+	qd := makeTestQueryDiffer(t, test.setup, test.query)
+
+	diffs, errs := qd.Stream(context.Background())
+
+	var actual []diffRow
+	for rd := range diffs {
+		actual = append(actual, diffRow{from: rd.From, to: rd.To})
+	}
+	assert.NoError(t, <-errs)
+	assert.Equal(t, test.diffRows, actual)
+	require.NoError(t, qd.Close())
+
+	// cancel after receiving the first diff
+	qd = makeTestQueryDiffer(t, test.setup, test.query)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	diffs, errs = qd.Stream(ctx)
+	rd, ok := <-diffs
+	require.True(t, ok)
+	assert.Equal(t, test.diffRows[0], diffRow{from: rd.From, to: rd.To})
+	cancel()
+
+	select {
+	case err := <-errs:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream was not stopped after the context was canceled")
+	}
+
+	_, ok = <-diffs
+	assert.False(t, ok)
+	require.NoError(t, qd.Close())
+}
+
+func TestQueryDifferProgressCallback(t *testing.T) {
+	test := queryDifferTests[2]
+	qd := makeTestQueryDiffer(t, test.setup, test.query)
+
+	type progress struct {
+		rowsRead, diffsFound uint64
+	}
+	var reports []progress
+	qd.SetProgressCallback(func(rowsRead, diffsFound uint64) {
+		reports = append(reports, progress{rowsRead, diffsFound})
+	})
+	qd.SetProgressInterval(1)
+
+	var diffs uint64
+	for {
+		_, _, err := qd.NextDiff()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		diffs++
+	}
+	require.NoError(t, qd.Close())
+
+	require.True(t, len(reports) > 1)
+	for i := 1; i < len(reports); i++ {
+		assert.True(t, reports[i].rowsRead >= reports[i-1].rowsRead)
+		assert.True(t, reports[i].diffsFound >= reports[i-1].diffsFound)
+	}
+
+	last := reports[len(reports)-1]
+	assert.Equal(t, diffs, last.diffsFound)
+	assert.True(t, last.rowsRead >= diffs)
+}
+
+func TestQueryDifferWithKeyColumns(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 10 where pk = 1"}},
+		{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 2"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (9,-9)"}},
+	}
+	query := "select * from test order by c0"
+
+	// the changed row moves within the results, so without a key it is removed and added
+	qd := makeTestQueryDiffer(t, setup, query)
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: nil, to: sql.Row{int32(9), int32(-9)}},
+		{from: sql.Row{int32(1), int32(1)}, to: nil},
+		{from: sql.Row{int32(2), int32(2)}, to: nil},
+		{from: nil, to: sql.Row{int32(1), int32(10)}},
+	})
+
+	// with a key the changed row is modified, and rows are diffed in key order
+	qd = makeTestQueryDiffer(t, setup, query, querydiff.WithKeyColumns("PK"))
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(1), int32(1)}, to: sql.Row{int32(1), int32(10)}},
+		{from: sql.Row{int32(2), int32(2)}, to: nil},
+		{from: nil, to: sql.Row{int32(9), int32(-9)}},
+	})
+
+	rd, err := qd.NextRowDiff()
 	assert.Equal(t, io.EOF, err)
+	require.NoError(t, qd.Reset())
+	rd, err = qd.NextRowDiff()
+	require.NoError(t, err)
+	assert.Equal(t, querydiff.Modified, rd.Type)
+	require.NoError(t, qd.Close())
+
+	// keys must be unique
+	dupSetup := append(setup, testCommand{commands.SqlCmd{}, []string{"-q", "insert into test values (8,3)"}})
+	qd = makeTestQueryDiffer(t, dupSetup, query, querydiff.WithKeyColumns("c0"))
+	var nextErr error
+	for nextErr == nil {
+		_, _, nextErr = qd.NextDiff()
+	}
+	assert.NotEqual(t, io.EOF, nextErr)
+
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+	_, err = querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, query, querydiff.WithKeyColumns("x"))
+	assert.Error(t, err)
+	_, err = querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, query+" limit 2", querydiff.WithKeyColumns("pk"))
+	assert.Error(t, err)
+}
+
+func TestQueryDifferNextGroupDiff(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "create table sales (pk int not null primary key, region varchar(20), amount int)"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into sales values (1,'east',10), (2,'east',5), (3,'west',7), (4,'north',1)"}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "sales"}},
+		{commands.SqlCmd{}, []string{"-q", "update sales set amount = 15 where pk = 2"}},
+		{commands.SqlCmd{}, []string{"-q", "delete from sales where pk = 4"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into sales values (5,'south',3)"}},
+	}
+	query := "select region, sum(amount) as total, count(*) as n from sales group by region order by region"
+
+	qd := makeTestQueryDiffer(t, setup, query)
+	_, err := qd.NextGroupDiff()
+	assert.Error(t, err)
+	require.NoError(t, qd.Close())
+
+	qd = makeTestQueryDiffer(t, setup, query, querydiff.WithKeyColumns("region"))
+
+	// the count of the east region is unchanged, so only its total has a delta
+	gd, err := qd.NextGroupDiff()
+	require.NoError(t, err)
+	assert.Equal(t, sql.Row{"east"}, gd.Key)
+	assert.Equal(t, querydiff.Modified, gd.Type)
+	require.Len(t, gd.Deltas, 1)
+	assert.Equal(t, "total", gd.Deltas[0].Column)
+	assert.True(t, gd.Deltas[0].Numeric)
+	assert.Equal(t, 10.0, gd.Deltas[0].Delta)
+
+	gd, err = qd.NextGroupDiff()
+	require.NoError(t, err)
+	assert.Equal(t, sql.Row{"north"}, gd.Key)
+	assert.Equal(t, querydiff.Removed, gd.Type)
+	require.Len(t, gd.Deltas, 2)
+	assert.Equal(t, "n", gd.Deltas[1].Column)
+	assert.Equal(t, int64(1), gd.Deltas[1].From)
+	assert.Nil(t, gd.Deltas[1].To)
+	assert.False(t, gd.Deltas[1].Numeric)
+
+	gd, err = qd.NextGroupDiff()
+	require.NoError(t, err)
+	assert.Equal(t, sql.Row{"south"}, gd.Key)
+	assert.Equal(t, querydiff.Added, gd.Type)
+	require.Len(t, gd.Deltas, 2)
+
+	_, err = qd.NextGroupDiff()
+	assert.Equal(t, io.EOF, err)
+	require.NoError(t, qd.Close())
+}
+
+func TestQueryDifferEmitUnchanged(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 1"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 20 where pk = 2"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (9,9)"}},
+	}
+	qd := makeTestQueryDiffer(t, setup, "select * from test order by pk")
+	qd.SetEmitUnchanged(true)
+
+	expected := []querydiff.RowDiff{
+		{From: sql.Row{int32(0), int32(0)}, To: sql.Row{int32(0), int32(0)}, Type: querydiff.Unchanged},
+		{From: sql.Row{int32(1), int32(1)}, To: nil, Type: querydiff.Removed},
+		{From: sql.Row{int32(2), int32(2)}, To: sql.Row{int32(2), int32(20)}, Type: querydiff.Modified},
+		{From: sql.Row{int32(3), int32(3)}, To: sql.Row{int32(3), int32(3)}, Type: querydiff.Unchanged},
+		{From: nil, To: sql.Row{int32(9), int32(9)}, Type: querydiff.Added},
+	}
+
+	readAll := func() []querydiff.RowDiff {
+		var actual []querydiff.RowDiff
+		for {
+			rd, err := qd.NextRowDiff()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			actual = append(actual, rd)
+		}
+		return actual
+	}
+	assert.Equal(t, expected, readAll())
+
+	// without unchanged rows only the diffs are returned
+	require.NoError(t, qd.Reset())
+	qd.SetEmitUnchanged(false)
+	assert.Equal(t, []querydiff.RowDiff{expected[1], expected[2], expected[4]}, readAll())
+	require.NoError(t, qd.Close())
+
+	// the query is run for identical roots when unchanged rows are emitted, and every row is unchanged
+	dEnv, fromRoot, _ := makeTestRoots(t, nil)
+	qd, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, fromRoot, "select * from test order by pk")
+	require.NoError(t, err)
+	qd.SetEmitUnchanged(true)
+
+	var unchanged []querydiff.RowDiff
+	for i := 0; i < 4; i++ {
+		r := sql.Row{int32(i), int32(i)}
+		unchanged = append(unchanged, querydiff.RowDiff{From: r, To: r, Type: querydiff.Unchanged})
+	}
+	assert.Equal(t, unchanged, readAll())
+
+	require.NoError(t, qd.Reset())
+	qd.SetEmitUnchanged(false)
+	assert.Empty(t, readAll())
+	require.NoError(t, qd.Close())
+}
+
+func TestQueryDifferLimitPushdown(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (4,4), (5,5), (6,6), (7,7)"}},
+	}
+	qd := makeTestQueryDiffer(t, setup, "select * from test order by pk desc limit 2")
+
+	// the top 2 rows of each root are diffed, rather than the first 2 diffs of all rows
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: nil, to: sql.Row{int32(7), int32(7)}},
+		{from: nil, to: sql.Row{int32(6), int32(6)}},
+		{from: sql.Row{int32(3), int32(3)}, to: nil},
+		{from: sql.Row{int32(2), int32(2)}, to: nil},
+	})
+	require.NoError(t, qd.Close())
+}
+
+func TestQueryDifferNullTransitions(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (4,NULL), (5,NULL)"}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "null rows"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = NULL where pk in (1, 2)"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 4 where pk = 4"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 30 where pk = 3"}},
+		// added and removed rows are not transitions, even if they have NULLs
+		{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 5"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (6,NULL)"}},
+	}
+	qd := makeTestQueryDiffer(t, setup, "select * from test order by pk")
+	assert.Empty(t, qd.NullTransitions())
+
+	expected := map[string]querydiff.NullTransition{
+		"c0": {Added: 2, Removed: 1},
+	}
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(1), int32(1)}, to: sql.Row{int32(1), nil}},
+		{from: sql.Row{int32(2), int32(2)}, to: sql.Row{int32(2), nil}},
+		{from: sql.Row{int32(3), int32(3)}, to: sql.Row{int32(3), int32(30)}},
+		{from: sql.Row{int32(4), nil}, to: sql.Row{int32(4), int32(4)}},
+		{from: sql.Row{int32(5), nil}, to: nil},
+		{from: nil, to: sql.Row{int32(6), nil}},
+	})
+	assert.Equal(t, expected, qd.NullTransitions())
+
+	require.NoError(t, qd.Reset())
+	assert.Empty(t, qd.NullTransitions())
+	_, _, err := qd.NextDiff()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]querydiff.NullTransition{"c0": {Added: 1}}, qd.NullTransitions())
+	require.NoError(t, qd.Close())
+}
+
+func TestQueryDifferNextPage(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 1"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 20 where pk = 2"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (4,4), (5,5), (6,6)"}},
+	}
+	query := "select * from test order by pk"
+	qd := makeTestQueryDiffer(t, setup, query)
+
+	_, _, err := qd.NextPage(0)
+	assert.Error(t, err)
+
+	page, more, err := qd.NextPage(2)
+	require.NoError(t, err)
+	assert.True(t, more)
+	assert.Equal(t, []querydiff.RowDiff{
+		{From: sql.Row{int32(1), int32(1)}, To: nil, Type: querydiff.Removed},
+		{From: sql.Row{int32(2), int32(2)}, To: sql.Row{int32(2), int32(20)}, Type: querydiff.Modified},
+	}, page)
+
+	page, more, err = qd.NextPage(2)
+	require.NoError(t, err)
+	assert.True(t, more)
+	assert.Equal(t, []querydiff.RowDiff{
+		{From: nil, To: sql.Row{int32(4), int32(4)}, Type: querydiff.Added},
+		{From: nil, To: sql.Row{int32(5), int32(5)}, Type: querydiff.Added},
+	}, page)
+
+	page, more, err = qd.NextPage(2)
+	require.NoError(t, err)
+	assert.False(t, more)
+	assert.Equal(t, []querydiff.RowDiff{
+		{From: nil, To: sql.Row{int32(6), int32(6)}, Type: querydiff.Added},
+	}, page)
+
+	page, more, err = qd.NextPage(2)
+	require.NoError(t, err)
+	assert.False(t, more)
+	assert.Empty(t, page)
+	require.NoError(t, qd.Close())
+
+	// a page which ends with the last diff has no more diffs after it
+	qd = makeTestQueryDiffer(t, setup, query)
+	page, more, err = qd.NextPage(5)
+	require.NoError(t, err)
+	assert.False(t, more)
+	assert.Len(t, page, 5)
+	require.NoError(t, qd.Close())
+
+	// the diff read ahead by NextPage is returned by NextDiff, and the caller can stop mid-stream
+	qd = makeTestQueryDiffer(t, setup, query)
+	_, more, err = qd.NextPage(1)
+	require.NoError(t, err)
+	assert.True(t, more)
+	from, to, err := qd.NextDiff()
+	require.NoError(t, err)
+	assert.Equal(t, sql.Row{int32(2), int32(2)}, from)
+	assert.Equal(t, sql.Row{int32(2), int32(20)}, to)
+	require.NoError(t, qd.Close())
+}
+
+func TestQueryDifferHasDiff(t *testing.T) {
+	query := "select * from test order by pk"
+
+	// the quiz table is changed, but not the results of the query
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "update quiz set c0 = 0 where pk = 1"}},
+	}
+	qd := makeTestQueryDiffer(t, setup, query)
+	qd.SetEmitUnchanged(true)
+	hasDiff, err := qd.HasDiff()
+	require.NoError(t, err)
+	assert.False(t, hasDiff)
+
+	setup = []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (4,4), (5,5), (6,6), (7,7)"}},
+		{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 1"}},
+	}
+	qd = makeTestQueryDiffer(t, setup, query)
+	var rowsRead uint64
+	qd.SetProgressCallback(func(read, _ uint64) {
+		rowsRead = read
+	})
+	qd.SetProgressInterval(1)
+	hasDiff, err = qd.HasDiff()
+	require.NoError(t, err)
+	assert.True(t, hasDiff)
+	// reading stopped at the first diff, after the row with pk 0 from both roots and the
+	// row with pk 1 from the from root
+	assert.Equal(t, uint64(3), rowsRead)
+
+	// the diffs can still be read after a reset
+	require.NoError(t, qd.Reset())
+	testQueryDifferRows(t, qd, []diffRow{
+		{from: sql.Row{int32(1), int32(1)}, to: nil},
+		{from: nil, to: sql.Row{int32(4), int32(4)}},
+		{from: nil, to: sql.Row{int32(5), int32(5)}},
+		{from: nil, to: sql.Row{int32(6), int32(6)}},
+		{from: nil, to: sql.Row{int32(7), int32(7)}},
+	})
+	require.NoError(t, qd.Close())
+
+	dEnv, fromRoot, _ := makeTestRoots(t, nil)
+	qd, err = querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, fromRoot, query)
+	require.NoError(t, err)
+	hasDiff, err = qd.HasDiff()
+	require.NoError(t, err)
+	assert.False(t, hasDiff)
+}
+
+func TestQueryDifferCountOnly(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 1"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 20 where pk = 2"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values (4,4), (5,5), (6,6)"}},
+	}
+	query := "select * from test order by pk"
+	qd := makeTestQueryDiffer(t, setup, query)
+	qd.SetEmitUnchanged(true)
+
+	counts := make(map[querydiff.DiffType]uint64)
+	for {
+		rd, err := qd.NextRowDiff()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		counts[rd.Type]++
+	}
+
+	require.NoError(t, qd.Reset())
+	added, removed, modified, err := qd.CountOnly()
+	require.NoError(t, err)
+	assert.Equal(t, counts[querydiff.Added], added)
+	assert.Equal(t, counts[querydiff.Removed], removed)
+	assert.Equal(t, counts[querydiff.Modified], modified)
+	assert.Equal(t, uint64(3), added)
+	assert.Equal(t, uint64(1), removed)
+	assert.Equal(t, uint64(1), modified)
+
+	// counting resumes from the current position of the differ
+	require.NoError(t, qd.Reset())
+	qd.SetEmitUnchanged(false)
+	rd, err := qd.NextRowDiff()
+	require.NoError(t, err)
+	assert.Equal(t, querydiff.Removed, rd.Type)
+	added, removed, modified, err = qd.CountOnly()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), added)
+	assert.Equal(t, uint64(0), removed)
+	assert.Equal(t, uint64(1), modified)
+
+	dEnv, fromRoot, _ := makeTestRoots(t, nil)
+	qd, err = querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, fromRoot, query)
+	require.NoError(t, err)
+	added, removed, modified, err = qd.CountOnly()
+	require.NoError(t, err)
+	assert.Zero(t, added+removed+modified)
+}
+
+func TestQueryDifferSpillThreshold(t *testing.T) {
+	var values, events []string
+	for i := 4; i < 200; i++ {
+		values = append(values, fmt.Sprintf("(%d,%d)", i, i%17))
+		events = append(events, fmt.Sprintf("(%d,%d.%02d,'2020-06-%02d %02d:00:00')", i, i%23, i%100, i%28+1, i%24))
+	}
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "create table events (pk int not null primary key, amount decimal(10,2), at datetime)"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into events values " + strings.Join(events, ", ")}},
+		{commands.AddCmd{}, []string{"."}},
+		{commands.CommitCmd{}, []string{"-m", "events"}},
+		{commands.SqlCmd{}, []string{"-q", "insert into test values " + strings.Join(values, ", ")}},
+		{commands.SqlCmd{}, []string{"-q", "delete from test where pk = 2"}},
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 30 where pk = 3"}},
+		{commands.SqlCmd{}, []string{"-q", "delete from events where pk = 5"}},
+		{commands.SqlCmd{}, []string{"-q", "update events set amount = 1.50, at = '2021-01-01 00:00:00' where pk = 6"}},
+	}
+
+	readAll := func(qd *querydiff.QueryDiffer) []querydiff.RowDiff {
+		var actual []querydiff.RowDiff
+		for {
+			rd, err := qd.NextRowDiff()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			actual = append(actual, rd)
+		}
+		require.NoError(t, qd.Close())
+		return actual
+	}
+
+	queries := []string{
+		"select * from test order by c0 desc, pk",
+		"select distinct c0 from test",
+		"select * from events order by amount, pk",
+		"select pk, at from events order by at desc, pk",
+	}
+	for _, query := range queries {
+		t.Run(query, func(t *testing.T) {
+			expected := readAll(makeTestQueryDiffer(t, setup, query))
+			require.NotEmpty(t, expected)
+
+			// a threshold this small spills the rows of each root to many files
+			actual := readAll(makeTestQueryDiffer(t, setup, query, querydiff.WithSpillThreshold(256)))
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestQueryDifferIdenticalRoots(t *testing.T) {
+	dEnv, fromRoot, _ := makeTestRoots(t, nil)
+
+	// the query plans of identical roots are built, but the query is not run, so no rows are read
+	query := "select pk, c0, concat('a,', c0) as s from test order by pk"
+	qd, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, fromRoot, query)
+	require.NoError(t, err)
+	require.Len(t, qd.Schema(), 3)
+	assert.Equal(t, "pk", qd.Schema()[0].Name)
+	assert.Equal(t, "c0", qd.Schema()[1].Name)
+	assert.Equal(t, "s", qd.Schema()[2].Name)
+	assert.Contains(t, qd.DiffPlanString(), "QueryDiff(from)")
+
+	rowsRead := uint64(math.MaxUint64)
+	qd.SetProgressCallback(func(read, _ uint64) {
+		rowsRead = read
+	})
+	testQueryDifferRows(t, qd, nil)
+	assert.Equal(t, uint64(0), rowsRead)
+
+	require.NoError(t, qd.Reset())
+	var buf bytes.Buffer
+	require.NoError(t, qd.WriteCSV(&buf))
+	assert.Equal(t, "diff_type,pk,c0,s\n", buf.String())
+
+	from, to := sql.Row{int32(1), int32(1), "a,1"}, sql.Row{int32(1), int32(2), "a,2"}
+	changed, err := qd.ChangedColumns(from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, changed)
+	require.NoError(t, qd.Close())
+
+	qd, err = querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, fromRoot, query, querydiff.WithColumns("c0"))
+	require.NoError(t, err)
+	require.Len(t, qd.Schema(), 1)
+	assert.Equal(t, "c0", qd.Schema()[0].Name)
+	require.NoError(t, qd.Close())
+
+	// queries and options are validated as they are for roots which differ
+	_, err = querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, fromRoot, "select * from missing order by pk")
+	assert.Error(t, err)
+	_, err = querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, fromRoot, "select * from test")
+	assert.Error(t, err)
+	_, err = querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, fromRoot, query, querydiff.WithKeyColumns("x"))
+	assert.Error(t, err)
+}
+
+func TestQueryDifferWindowFunction(t *testing.T) {
+	setup := []testCommand{
+		{commands.SqlCmd{}, []string{"-q", "update test set c0 = 10 where pk = 1"}},
+	}
+	dEnv, fromRoot, toRoot := makeTestRoots(t, setup)
+
+	// the sql engine has no window functions, so the query fails to parse rather than being diffed
+	query := "select pk, row_number() over (order by c0) as rn from test order by pk"
+	assert.NotPanics(t, func() {
+		_, err := querydiff.MakeQueryDiffer(context.Background(), dEnv, fromRoot, toRoot, query)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "syntax error")
+	})
 }
@@ -0,0 +1,113 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"fmt"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+)
+
+// CompareRows compares two rows according to |fields|. It returns a negative number if |left| is ordered
+// before |right|, a positive number if it is ordered after |right|, and 0 if the rows are equal on every
+// field. Descending fields and NULL ordering are honored, so the result follows the order in which a
+// plan.Sort node with the same fields would produce the rows.
+func CompareRows(ctx *sql.Context, fields []plan.SortField, left, right sql.Row) (int, error) {
+	return compareRows(ctx, ctx, fields, fields, left, right)
+}
+
+// SortFieldsFromColumns builds the sort fields which order rows of schema |sch| by the columns named
+// in |cols|, with the corresponding order from |orders|. Columns are matched by name, ignoring case.
+// NULLs are ordered before all other values, as in MySQL, so they come first in ascending order and
+// last in descending order.
+func SortFieldsFromColumns(sch sql.Schema, cols []string, orders []plan.SortOrder) ([]plan.SortField, error) {
+	if len(cols) != len(orders) {
+		return nil, fmt.Errorf("%d columns given with %d sort orders", len(cols), len(orders))
+	}
+
+	fields := make([]plan.SortField, len(cols))
+	for i, name := range cols {
+		idx := columnIndex(sch, name)
+		if idx < 0 {
+			return nil, fmt.Errorf("column '%s' not found", name)
+		}
+
+		col := sch[idx]
+		fields[i] = plan.SortField{
+			Column:       expression.NewGetFieldWithTable(idx, col.Type, col.Source, col.Name, col.Nullable),
+			Order:        orders[i],
+			NullOrdering: plan.NullsFirst,
+		}
+	}
+
+	return fields, nil
+}
+
+// compareRows is CompareRows with separate contexts and sort fields for evaluating |left| and |right|.
+// The sort fields of each side are bound to the schema of that side's rows, which may differ when
+// the rows come from different query plans. |leftFields| and |rightFields| must have the same
+// length, order and null ordering.
+func compareRows(leftCtx, rightCtx *sql.Context, leftFields, rightFields []plan.SortField, left, right sql.Row) (int, error) {
+	if left == nil || right == nil {
+		return 0, fmt.Errorf("nil rows cannot be compared")
+	}
+	if len(leftFields) != len(rightFields) {
+		return 0, fmt.Errorf("rows cannot be compared with %d and %d sort fields", len(leftFields), len(rightFields))
+	}
+
+	for i, sf := range leftFields {
+		typ := sf.Column.Type()
+		lv, err := sf.Column.Eval(leftCtx, left)
+		if err != nil {
+			return 0, fmt.Errorf("error evaluating sort field %s of row %v: %s", sf.Column.String(), left, err.Error())
+		}
+
+		rv, err := rightFields[i].Column.Eval(rightCtx, right)
+		if err != nil {
+			return 0, fmt.Errorf("error evaluating sort field %s of row %v: %s", rightFields[i].Column.String(), right, err.Error())
+		}
+
+		if sf.Order == plan.Descending {
+			lv, rv = rv, lv
+		}
+
+		if lv == nil && rv == nil {
+			continue
+		} else if lv == nil {
+			if sf.NullOrdering == plan.NullsFirst {
+				return -1, nil
+			} else {
+				return 1, nil
+			}
+		} else if rv == nil {
+			if sf.NullOrdering == plan.NullsFirst {
+				return 1, nil
+			} else {
+				return -1, nil
+			}
+		}
+
+		cmp, err := typ.Compare(lv, rv)
+		if err != nil {
+			return 0, fmt.Errorf("error comparing sort field %s of rows %v and %v: %s", sf.Column.String(), left, right, err.Error())
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return 0, nil
+}
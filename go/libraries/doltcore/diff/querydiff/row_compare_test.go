@@ -0,0 +1,124 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff/querydiff"
+)
+
+func TestCompareRows(t *testing.T) {
+	c0 := expression.NewGetField(0, sql.Int64, "c0", true)
+	c1 := expression.NewGetField(1, sql.Int64, "c1", true)
+
+	asc := []plan.SortField{{Column: c0, Order: plan.Ascending, NullOrdering: plan.NullsFirst}}
+	desc := []plan.SortField{{Column: c0, Order: plan.Descending, NullOrdering: plan.NullsFirst}}
+	nullsLast := []plan.SortField{{Column: c0, Order: plan.Ascending, NullOrdering: plan.NullsLast}}
+	multi := []plan.SortField{
+		{Column: c0, Order: plan.Ascending, NullOrdering: plan.NullsFirst},
+		{Column: c1, Order: plan.Descending, NullOrdering: plan.NullsFirst},
+	}
+
+	tests := []struct {
+		name     string
+		fields   []plan.SortField
+		left     sql.Row
+		right    sql.Row
+		expected int
+	}{
+		{"ascending lesser", asc, sql.NewRow(int64(0), nil), sql.NewRow(int64(1), nil), -1},
+		{"ascending equal", asc, sql.NewRow(int64(1), nil), sql.NewRow(int64(1), nil), 0},
+		{"ascending greater", asc, sql.NewRow(int64(2), nil), sql.NewRow(int64(1), nil), 1},
+		{"descending lesser", desc, sql.NewRow(int64(0), nil), sql.NewRow(int64(1), nil), 1},
+		{"descending equal", desc, sql.NewRow(int64(1), nil), sql.NewRow(int64(1), nil), 0},
+		{"descending greater", desc, sql.NewRow(int64(2), nil), sql.NewRow(int64(1), nil), -1},
+		{"nulls first", asc, sql.NewRow(nil, nil), sql.NewRow(int64(1), nil), -1},
+		{"nulls first reversed", asc, sql.NewRow(int64(1), nil), sql.NewRow(nil, nil), 1},
+		{"nulls last", nullsLast, sql.NewRow(nil, nil), sql.NewRow(int64(1), nil), 1},
+		{"nulls last reversed", nullsLast, sql.NewRow(int64(1), nil), sql.NewRow(nil, nil), -1},
+		{"both null", asc, sql.NewRow(nil, nil), sql.NewRow(nil, nil), 0},
+		{"multi first field decides", multi, sql.NewRow(int64(0), int64(0)), sql.NewRow(int64(1), int64(1)), -1},
+		{"multi second field decides", multi, sql.NewRow(int64(1), int64(0)), sql.NewRow(int64(1), int64(1)), 1},
+		{"multi equal", multi, sql.NewRow(int64(1), int64(1)), sql.NewRow(int64(1), int64(1)), 0},
+	}
+
+	ctx := sql.NewContext(context.Background())
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmp, err := querydiff.CompareRows(ctx, test.fields, test.left, test.right)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, cmp)
+		})
+	}
+}
+
+func TestCompareRowsErrors(t *testing.T) {
+	ctx := sql.NewContext(context.Background())
+	fields := []plan.SortField{
+		{Column: expression.NewGetField(0, sql.Int64, "c0", true), Order: plan.Ascending, NullOrdering: plan.NullsFirst},
+	}
+
+	assert.NotPanics(t, func() {
+		_, err := querydiff.CompareRows(ctx, fields, nil, sql.NewRow(int64(1)))
+		assert.Error(t, err)
+		_, err = querydiff.CompareRows(ctx, fields, sql.NewRow(int64(1)), nil)
+		assert.Error(t, err)
+	})
+
+	_, err := querydiff.CompareRows(ctx, fields, sql.NewRow("abc"), sql.NewRow(int64(1)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "c0")
+}
+
+func TestSortFieldsFromColumns(t *testing.T) {
+	sch := sql.Schema{
+		&sql.Column{Name: "pk", Type: sql.Int64, Source: "test"},
+		&sql.Column{Name: "c0", Type: sql.Int64, Source: "test", Nullable: true},
+	}
+
+	fields, err := querydiff.SortFieldsFromColumns(sch, []string{"C0", "pk"}, []plan.SortOrder{plan.Descending, plan.Ascending})
+	require.NoError(t, err)
+	require.Len(t, fields, 2)
+	assert.Equal(t, plan.Descending, fields[0].Order)
+	assert.Equal(t, plan.Ascending, fields[1].Order)
+
+	ctx := sql.NewContext(context.Background())
+	rows := []sql.Row{
+		sql.NewRow(int64(0), int64(5)),
+		sql.NewRow(int64(1), int64(5)),
+		sql.NewRow(int64(2), int64(3)),
+		sql.NewRow(int64(3), nil),
+	}
+	for i := 1; i < len(rows); i++ {
+		cmp, err := querydiff.CompareRows(ctx, fields, rows[i-1], rows[i])
+		require.NoError(t, err)
+		assert.Equal(t, -1, cmp)
+	}
+
+	_, err = querydiff.SortFieldsFromColumns(sch, []string{"c1"}, []plan.SortOrder{plan.Ascending})
+	assert.Error(t, err)
+
+	_, err = querydiff.SortFieldsFromColumns(sch, []string{"pk", "c0"}, []plan.SortOrder{plan.Ascending})
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+)
+
+// DiffType classifies a RowDiff
+type DiffType int
+
+const (
+	// Added is a row that is only in the "to" query results
+	Added DiffType = iota
+
+	// Removed is a row that is only in the "from" query results
+	Removed
+
+	// Modified is a row that sorts equally in both query results, but whose values differ
+	Modified
+
+	// Unchanged is a row that is equal in both query results. Unchanged rows are only returned by a
+	// QueryDiffer after SetEmitUnchanged(true).
+	Unchanged
+)
+
+func (dt DiffType) String() string {
+	switch dt {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	case Unchanged:
+		return "unchanged"
+	default:
+		return "unknown"
+	}
+}
+
+// RowDiff is a single difference between the "from" and "to" query results.
+type RowDiff struct {
+	From sql.Row
+	To   sql.Row
+	Type DiffType
+}
+
+func newRowDiff(from, to sql.Row) RowDiff {
+	dt := Modified
+	if from == nil {
+		dt = Added
+	} else if to == nil {
+		dt = Removed
+	}
+
+	return RowDiff{From: from, To: to, Type: dt}
+}
+
+// NextRowDiff returns the next diff as a classified RowDiff. Returns io.EOF once all diffs have been returned.
+func (qd *QueryDiffer) NextRowDiff() (RowDiff, error) {
+	from, to, unchanged, err := qd.next()
+	if err != nil {
+		return RowDiff{}, err
+	}
+	if unchanged {
+		return RowDiff{From: from, To: to, Type: Unchanged}, nil
+	}
+	return newRowDiff(from, to), nil
+}
+
+// NextPage returns up to |n| diffs, and whether more diffs remain after them. Each call resumes where
+// the previous one stopped, so diffs can be fetched a page at a time without holding all of them in
+// memory. To find whether more diffs remain, NextPage reads one diff ahead, which is returned first by
+// the next call to NextPage, NextDiff or NextRowDiff. A caller which stops paging before the last page
+// should Close the QueryDiffer.
+func (qd *QueryDiffer) NextPage(n int) ([]RowDiff, bool, error) {
+	if n <= 0 {
+		return nil, false, fmt.Errorf("page size must be positive, got %d", n)
+	}
+
+	page := make([]RowDiff, 0, n)
+	for len(page) < n {
+		rd, err := qd.NextRowDiff()
+		if err == io.EOF {
+			return page, false, nil
+		} else if err != nil {
+			return nil, false, err
+		}
+		page = append(page, rd)
+	}
+
+	rd, err := qd.NextRowDiff()
+	if err == io.EOF {
+		return page, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	qd.peeked = &rd
+
+	return page, true, nil
+}
+
+// Stream returns the diffs of the QueryDiffer on a channel, which is closed once all diffs have been sent. If an
+// error occurs, or |ctx| is canceled, it is sent on the returned error channel and the diff channel is closed
+// without sending the remaining diffs. The error channel is closed after the diff channel, so receiving from it
+// once the diff channel is closed returns nil if all diffs were sent.
+func (qd *QueryDiffer) Stream(ctx context.Context) (<-chan RowDiff, <-chan error) {
+	diffs := make(chan RowDiff)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(diffs)
+
+		for {
+			if ctx.Err() != nil {
+				errs <- ctx.Err()
+				return
+			}
+
+			rd, err := qd.NextRowDiff()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case diffs <- rd:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return diffs, errs
+}
+
+// ChangedColumns returns the indexes of the columns whose values differ between |from| and |to|. Values
+// are compared using the column's type. A column that changes to or from NULL is reported as changed.
+func (qd *QueryDiffer) ChangedColumns(from, to sql.Row) ([]int, error) {
+	if len(from) != len(qd.sch) || len(to) != len(qd.sch) {
+		return nil, fmt.Errorf("rows must have %d columns to be compared", len(qd.sch))
+	}
+
+	var changed []int
+	for i, col := range qd.sch {
+		fv, tv := from[i], to[i]
+
+		if fv == nil && tv == nil {
+			continue
+		} else if fv == nil || tv == nil {
+			changed = append(changed, i)
+			continue
+		}
+
+		cmp, err := col.Type.Compare(fv, tv)
+		if err != nil {
+			return nil, err
+		}
+
+		if cmp != 0 {
+			changed = append(changed, i)
+		}
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/shopspring/decimal"
+
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// RowKeyTuple builds a Tuple of the values of the columns of |row| at the indexes |keyCols|, in
+// that order. The tuples of rows with equal keys are equal, so rows from the results of each root
+// can be matched by key. An error is returned if a key column is out of range, is NULL, or has a
+// value whose type cannot be converted to a noms value.
+func RowKeyTuple(nbf *types.NomsBinFormat, row sql.Row, keyCols []int) (types.Tuple, error) {
+	vals := make([]types.Value, len(keyCols))
+	for i, col := range keyCols {
+		if col < 0 || col >= len(row) {
+			return types.EmptyTuple(nbf), fmt.Errorf("key column %d is out of range for a row with %d columns", col, len(row))
+		}
+
+		v, err := keyValue(row[col])
+		if err != nil {
+			return types.EmptyTuple(nbf), fmt.Errorf("key column %d: %s", col, err.Error())
+		}
+		vals[i] = v
+	}
+
+	return types.NewTuple(nbf, vals...)
+}
+
+// keyValue converts the value of a key column to a noms value.
+func keyValue(v interface{}) (types.Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("value is NULL")
+	case int:
+		return types.Int(val), nil
+	case int8:
+		return types.Int(val), nil
+	case int16:
+		return types.Int(val), nil
+	case int32:
+		return types.Int(val), nil
+	case int64:
+		return types.Int(val), nil
+	case uint:
+		return types.Uint(val), nil
+	case uint8:
+		return types.Uint(val), nil
+	case uint16:
+		return types.Uint(val), nil
+	case uint32:
+		return types.Uint(val), nil
+	case uint64:
+		return types.Uint(val), nil
+	case float32:
+		return types.Float(val), nil
+	case float64:
+		return types.Float(val), nil
+	case bool:
+		return types.Bool(val), nil
+	case string:
+		return types.String(val), nil
+	case []byte:
+		return types.InlineBlob(val), nil
+	case time.Time:
+		return types.Timestamp(val), nil
+	case decimal.Decimal:
+		return types.Decimal(val), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", v)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff/querydiff"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestRowKeyTuple(t *testing.T) {
+	nbf := types.Format_Default
+	ts := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	row := sql.NewRow(int32(1), "abc", uint64(7), 2.5, true, []byte{1, 2}, ts, nil, struct{}{})
+
+	tpl, err := querydiff.RowKeyTuple(nbf, row, []int{1, 0})
+	require.NoError(t, err)
+	expected, err := types.NewTuple(nbf, types.String("abc"), types.Int(1))
+	require.NoError(t, err)
+	assert.True(t, expected.Equals(tpl))
+
+	tpl, err = querydiff.RowKeyTuple(nbf, row, []int{2, 3, 4, 5, 6})
+	require.NoError(t, err)
+	expected, err = types.NewTuple(nbf, types.Uint(7), types.Float(2.5), types.Bool(true), types.InlineBlob{1, 2}, types.Timestamp(ts))
+	require.NoError(t, err)
+	assert.True(t, expected.Equals(tpl))
+
+	// keys of equal values of different int widths are equal
+	other, err := querydiff.RowKeyTuple(nbf, sql.NewRow(int64(1), "abc"), []int{1, 0})
+	require.NoError(t, err)
+	tpl, err = querydiff.RowKeyTuple(nbf, row, []int{1, 0})
+	require.NoError(t, err)
+	assert.True(t, other.Equals(tpl))
+
+	_, err = querydiff.RowKeyTuple(nbf, row, []int{7})
+	assert.Error(t, err)
+	_, err = querydiff.RowKeyTuple(nbf, row, []int{8})
+	assert.Error(t, err)
+	_, err = querydiff.RowKeyTuple(nbf, row, []int{9})
+	assert.Error(t, err)
+	_, err = querydiff.RowKeyTuple(nbf, row, []int{-1})
+	assert.Error(t, err)
+}
@@ -15,9 +15,12 @@
 package querydiff
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"math"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/sqlutil"
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
 
 	"github.com/liquidata-inc/go-mysql-server/sql"
@@ -33,6 +36,8 @@ const (
 	unknown rowCmp = math.MaxInt32
 )
 
+var errIteratorsOutOfOrder = errors.New("query diff iterators called out of order")
+
 // nodeDiffer is used to create a modified query plan to be used by
 // QueryDiffer to diff a query.
 // Given two identical query plans ("from", "to") created from two
@@ -43,90 +48,259 @@ const (
 type nodeDiffer interface {
 	makeFromNode() sql.Node
 	makeToNode() sql.Node
+
+	// reset discards any buffered state and restarts
+	// iteration over the child query nodes.
+	reset() error
 }
 
-func newSortNodeDiffer(fromCtx, toCtx *sql.Context, from, to *plan.Sort) (nodeDiffer, error) {
-	fromIter, err := from.RowIter(fromCtx)
-	if err != nil {
-		return nil, err
+// newSortNodeDiffer creates a sortNodeDiffer which diffs the rows of |from| and |to|. If |limit| is
+// not noLimit, only the first |limit| rows of each sort node are diffed. If |distinct| is true,
+// duplicate rows of each sort node are diffed once. If |opts| has key columns, the rows of each sort
+// node must be sorted by key and have unique keys.
+func newSortNodeDiffer(fromCtx, toCtx *sql.Context, from, to *plan.Sort, opts queryDifferOpts, limit int64, distinct bool) (nodeDiffer, error) {
+	nd := &sortNodeDiffer{
+		fromCtx:    fromCtx,
+		toCtx:      toCtx,
+		fromChild:  from,
+		toChild:    to,
+		fromFields: from.SortFields,
+		toFields:   to.SortFields,
+		fromNorms:  columnNormalizations(from.Schema(), opts.normalize),
+		toNorms:    columnNormalizations(to.Schema(), opts.normalize),
+		fromTols:   schemaTolerances(from.Schema(), opts.floatTolerance),
+		toTols:     schemaTolerances(to.Schema(), opts.floatTolerance),
+		limit:      limit,
+		distinct:   distinct,
+		spillAt:    opts.spillThreshold,
+	}
+
+	var err error
+	if len(opts.keyColumns) > 0 {
+		nd.fromKeys, err = keyIndexes(from.Schema(), opts.keyColumns)
+		if err != nil {
+			return nil, err
+		}
+		nd.toKeys, err = keyIndexes(to.Schema(), opts.keyColumns)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	toIter, err := to.RowIter(toCtx)
+	err = nd.start()
 	if err != nil {
 		return nil, err
 	}
 
-	ae := atomicerr.New()
+	return nd, nil
+}
 
-	return &sortNodeDiffer{
+// newIterNodeDiffer creates a sortNodeDiffer which diffs the rows of two RowIters rather than
+// the results of two plan.Sort nodes. Both iterators must produce rows with schema |sch|, sorted
+// according to |sortFields|. The returned sortNodeDiffer cannot be reset.
+func newIterNodeDiffer(ctx *sql.Context, sch sql.Schema, sortFields []plan.SortField, from, to sql.RowIter) *sortNodeDiffer {
+	nd := &sortNodeDiffer{
+		fromCtx:    ctx,
+		toCtx:      ctx,
+		fromFields: sortFields,
+		toFields:   sortFields,
+		limit:      noLimit,
+	}
+
+	nd.startIters(newTiebreakIter(ctx, sch, nil, nil, sortFields, from), newTiebreakIter(ctx, sch, nil, nil, sortFields, to))
+
+	return nd
+}
+
+// newComparatorNodeDiffer creates a sortNodeDiffer which aligns the rows of |from| and |to| with |cmp|, rather than
+// with the SortFields of a plan.Sort node, so that rows can be matched up by a custom key. Both nodes must produce
+// their rows in the order defined by |cmp|, which returns lesser when |l| is produced before |r|. Rows for which |cmp|
+// returns equal are paired up in the order they are produced, as tied rows are not reordered.
+func newComparatorNodeDiffer(fromCtx, toCtx *sql.Context, from, to sql.Node, cmp func(l, r sql.Row) (rowCmp, error)) (nodeDiffer, error) {
+	nd := &sortNodeDiffer{
+		fromCtx:   fromCtx,
+		toCtx:     toCtx,
 		fromChild: from,
 		toChild:   to,
-		fromIter:  newIterQueue(fromIter, ae),
-		toIter:    newIterQueue(toIter, ae),
-		lastCmp:   unknown,
-		ae:        ae,
-	}, nil
+		cmp:       cmp,
+		limit:     noLimit,
+	}
+
+	err := nd.start()
+	if err != nil {
+		return nil, err
+	}
+
+	return nd, nil
 }
 
 type sortNodeDiffer struct {
-	ctx       *sql.Context
-	fromChild *plan.Sort
-	toChild   *plan.Sort
-	fromIter  *iterQueue
-	toIter    *iterQueue
-	lastCmp   rowCmp
-	ae        *atomicerr.AtomicError
+	fromCtx    *sql.Context
+	toCtx      *sql.Context
+	fromChild  sql.Node
+	toChild    sql.Node
+	fromFields []plan.SortField
+	toFields   []plan.SortField
+	// cmp aligns the rows of each child in place of fromFields and toFields, if it is non-nil
+	cmp func(l, r sql.Row) (rowCmp, error)
+	// fromNorms and toNorms hold the string normalization of each column of the child nodes, or nil
+	// if there are none
+	fromNorms []StringNormalization
+	toNorms   []StringNormalization
+	// fromTols and toTols hold the float tolerance of each column of the child nodes, or nil if there
+	// are none
+	fromTols []float64
+	toTols   []float64
+	limit    int64
+	distinct bool
+	// spillAt is the number of bytes of rows each sort node may buffer in memory, if it is positive
+	spillAt  int
+	fromKeys []int
+	toKeys   []int
+	fromIter *sqlutil.LookaheadRowIter
+	toIter   *sqlutil.LookaheadRowIter
+	lastCmp  rowCmp
+	ae       *atomicerr.AtomicError
+	// reportedErr is the error of ae once it has been returned by nextFromRow or nextToRow, so that
+	// close does not return it a second time
+	reportedErr error
+}
+
+func (nd *sortNodeDiffer) start() error {
+	fromIter, err := nd.childRowIter(nd.fromCtx, nd.fromChild)
+	if err != nil {
+		return err
+	}
+
+	toIter, err := nd.childRowIter(nd.toCtx, nd.toChild)
+	if err != nil {
+		return err
+	}
+
+	var from, to sql.RowIter = fromIter, toIter
+	if nd.cmp == nil {
+		from = newTiebreakIter(nd.fromCtx, nd.fromChild.Schema(), nd.fromNorms, nd.fromTols, nd.fromFields, fromIter)
+		to = newTiebreakIter(nd.toCtx, nd.toChild.Schema(), nd.toNorms, nd.toTols, nd.toFields, toIter)
+	}
+	if nd.distinct {
+		from, to = newDistinctRowIter(from, nd.fromChild.Schema()), newDistinctRowIter(to, nd.toChild.Schema())
+	}
+	if nd.fromKeys != nil {
+		from, to = newUniqueKeyIter(from, nd.fromKeys), newUniqueKeyIter(to, nd.toKeys)
+	}
+	if nd.limit != noLimit {
+		from, to = newLimitRowIter(from, nd.limit), newLimitRowIter(to, nd.limit)
+	}
+
+	nd.startIters(from, to)
+
+	return nil
+}
+
+// childRowIter returns the rows of |child|. If spillAt is set, the input of a sort node is sorted
+// by a spillSortIter rather than by the sort node, which sorts all of its input in memory.
+func (nd *sortNodeDiffer) childRowIter(ctx *sql.Context, child sql.Node) (sql.RowIter, error) {
+	sort, ok := child.(*plan.Sort)
+	if !ok || nd.spillAt <= 0 {
+		return child.RowIter(ctx)
+	}
+
+	iter, err := sort.Child.RowIter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newSpillSortIter(ctx, sort.SortFields, iter, nd.spillAt), nil
+}
+
+func (nd *sortNodeDiffer) startIters(fromIter, toIter sql.RowIter) {
+	nd.ae = atomicerr.New()
+	nd.fromIter = sqlutil.NewLookaheadRowIter(fromIter, nd.ae)
+	nd.toIter = sqlutil.NewLookaheadRowIter(toIter, nd.ae)
+	nd.lastCmp = unknown
+	nd.reportedErr = nil
+}
+
+func (nd *sortNodeDiffer) reset() error {
+	if nd.fromChild == nil {
+		return errCannotReset
+	}
+
+	err := nd.close()
+	if err != nil {
+		return err
+	}
+	return nd.start()
 }
 
 var _ nodeDiffer = &sortNodeDiffer{}
 
+// doneErr returns the error which ended either child iterator, if one did. The LookaheadRowIters
+// record errors rather than returning them, so they are checked as soon as an iterator is done,
+// before its end is treated as the end of its rows. An error returned here is not returned again
+// by close.
+func (nd *sortNodeDiffer) doneErr() error {
+	if nd.fromIter.IsDone() || nd.toIter.IsDone() {
+		nd.reportedErr = nd.ae.Get()
+		return nd.reportedErr
+	}
+	return nil
+}
+
 func (nd *sortNodeDiffer) nextFromRow() (sql.Row, error) {
-	nd.fromIter.maybeStart()
-	nd.toIter.maybeStart()
+	nd.fromIter.MaybeStart()
+	nd.toIter.MaybeStart()
 
-	if nd.fromIter.isDone() {
+	if err := nd.doneErr(); err != nil {
+		return nil, err
+	}
+
+	if nd.fromIter.IsDone() {
 		return nil, io.EOF
 	}
-	if nd.toIter.isDone() {
-		return nd.fromIter.pop(), nil
+	if nd.toIter.IsDone() {
+		return nd.fromIter.Pop(), nil
 	}
 
 	if nd.lastCmp != unknown {
-		panic("query diff iterators called out of order")
+		return nil, errIteratorsOutOfOrder
 	}
 
 	var err error
-	nd.lastCmp, err = nd.rowCompare(nd.fromIter.peek(), nd.toIter.peek())
+	nd.lastCmp, err = nd.rowCompare(nd.fromIter.Peek(), nd.toIter.Peek())
 	if err != nil {
 		return nil, err
 	}
 
 	switch nd.lastCmp {
 	case lesser:
-		return nd.fromIter.pop(), nil
+		return nd.fromIter.Pop(), nil
 	case equal:
-		return nd.fromIter.pop(), nil
+		return nd.fromIter.Pop(), nil
 	case greater:
 		return nil, errSkip
 	default:
-		panic("incorrect value fromIter rowCmp")
+		return nil, fmt.Errorf("unexpected row comparison result %d", nd.lastCmp)
 	}
 }
 
 func (nd *sortNodeDiffer) nextToRow() (sql.Row, error) {
-	nd.fromIter.maybeStart()
-	nd.toIter.maybeStart()
+	nd.fromIter.MaybeStart()
+	nd.toIter.MaybeStart()
+
+	if err := nd.doneErr(); err != nil {
+		return nil, err
+	}
 
-	if nd.toIter.isDone() {
+	if nd.toIter.IsDone() {
 		return nil, io.EOF
 	}
-	if nd.fromIter.isDone() && nd.lastCmp == unknown {
-		return nd.toIter.pop(), nil
+	if nd.fromIter.IsDone() && nd.lastCmp == unknown {
+		return nd.toIter.Pop(), nil
 	}
 	// if lastCmp != unknown, fromIter just popped its last item
 
 	if nd.lastCmp == unknown {
-		panic("query diff iterators called out of order")
+		return nil, errIteratorsOutOfOrder
 	}
 
 	cmp := unknown
@@ -136,65 +310,49 @@ func (nd *sortNodeDiffer) nextToRow() (sql.Row, error) {
 	case lesser:
 		return nil, errSkip
 	case equal:
-		return nd.toIter.pop(), nil
+		return nd.toIter.Pop(), nil
 	case greater:
-		return nd.toIter.pop(), nil
+		return nd.toIter.Pop(), nil
 	default:
-		panic("incorrect value fromIter rowCmp")
+		return nil, fmt.Errorf("unexpected row comparison result %d", cmp)
 	}
 }
 
+// rowCompare compares two rows according to the SortFields of the diffed plan.Sort node, or
+// with the comparator of a sortNodeDiffer created by newComparatorNodeDiffer.
+// The result is relative to the order in which rows are produced, rather than to the
+// ascending order of their values: descending fields have their operands swapped, so
+// lesser always means that |left| is produced before |right|. The merge logic in
+// nextFromRow and nextToRow relies on this to handle ascending and descending sorts alike.
+// Each row is evaluated with the SortFields of its own plan, as sort expressions are bound
+// to column indexes which may differ between the from and to schemas. Float tolerances and
+// string normalizations are applied by the sort fields themselves, by quantizeSort and
+// normalizeSort, so rows are compared in the same order in which the sort nodes produce them.
 func (nd *sortNodeDiffer) rowCompare(left, right sql.Row) (rowCmp, error) {
-	if left == nil || right == nil {
-		panic("nil rows cannot be compared")
+	if nd.cmp != nil {
+		return nd.cmp(left, right)
 	}
 
-	for _, sf := range nd.fromChild.SortFields {
-		typ := sf.Column.Type()
-		lv, err := sf.Column.Eval(nd.ctx, left)
-		if err != nil {
-			return unknown, err
-		}
-
-		rv, err := sf.Column.Eval(nd.ctx, right)
-		if err != nil {
-			return unknown, err
-		}
-
-		if sf.Order == plan.Descending {
-			lv, rv = rv, lv
-		}
-
-		if lv == nil && rv == nil {
-			continue
-		} else if lv == nil {
-			if sf.NullOrdering == plan.NullsFirst {
-				return lesser, nil
-			} else {
-				return greater, nil
-			}
-		} else if rv == nil {
-			if sf.NullOrdering == plan.NullsFirst {
-				return greater, nil
-			} else {
-				return lesser, nil
-			}
-		}
+	cmp, err := compareRows(nd.fromCtx, nd.toCtx, nd.fromFields, nd.toFields, left, right)
+	if err != nil {
+		return unknown, err
+	}
 
-		cmp, err := typ.Compare(lv, rv)
-		if err != nil {
-			return 0, err
-		}
-		if cmp != 0 {
-			return rowCmp(cmp), nil
-		}
+	// sql.Type.Compare implementations are only required to return the sign of the comparison
+	switch {
+	case cmp < 0:
+		return lesser, nil
+	case cmp > 0:
+		return greater, nil
+	default:
+		return equal, nil
 	}
-	return 0, nil
 }
 
 type sqlNodeWrapper struct {
 	sql.Node
-	iter rowIterWrapper
+	iter  rowIterWrapper
+	label string
 }
 
 var _ sql.Node = sqlNodeWrapper{}
@@ -203,6 +361,15 @@ func (w sqlNodeWrapper) RowIter(_ *sql.Context) (sql.RowIter, error) {
 	return w.iter, nil
 }
 
+// String renders the wrapped node beneath a QueryDiff node, marking where the
+// nodeDiffer was injected into the query plan.
+func (w sqlNodeWrapper) String() string {
+	p := sql.NewTreePrinter()
+	_ = p.WriteNode("QueryDiff(%s)", w.label)
+	_ = p.WriteChildren(w.Node.String())
+	return p.String()
+}
+
 type rowIterWrapper struct {
 	next  func() (sql.Row, error)
 	close func() error
@@ -220,34 +387,49 @@ func (w rowIterWrapper) Close() error {
 
 func (nd *sortNodeDiffer) makeFromNode() sql.Node {
 	return sqlNodeWrapper{
-		Node: nd.fromChild,
-		iter: rowIterWrapper{
-			next: func() (row sql.Row, err error) {
-				return nd.nextFromRow()
-			},
-			close: func() error {
-				return nil
-			},
-		},
+		Node:  nd.fromChild,
+		iter:  nd.fromRowIter(),
+		label: "from",
 	}
 }
 
 func (nd *sortNodeDiffer) makeToNode() sql.Node {
 	return sqlNodeWrapper{
-		Node: nd.toChild,
-		iter: rowIterWrapper{
-			next: func() (row sql.Row, err error) {
-				return nd.nextToRow()
-			},
-			close: func() error {
-				return nd.close()
-			},
+		Node:  nd.toChild,
+		iter:  nd.toRowIter(),
+		label: "to",
+	}
+}
+
+func (nd *sortNodeDiffer) fromRowIter() rowIterWrapper {
+	return rowIterWrapper{
+		next: func() (row sql.Row, err error) {
+			return nd.nextFromRow()
+		},
+		close: func() error {
+			return nil
+		},
+	}
+}
+
+func (nd *sortNodeDiffer) toRowIter() rowIterWrapper {
+	return rowIterWrapper{
+		next: func() (row sql.Row, err error) {
+			return nd.nextToRow()
+		},
+		close: func() error {
+			return nd.close()
 		},
 	}
 }
 
 func (nd *sortNodeDiffer) close() error {
-	nd.fromIter.close()
-	nd.toIter.close()
-	return nd.ae.Get()
+	nd.fromIter.Close()
+	nd.toIter.Close()
+
+	err := nd.ae.Get()
+	if err == nd.reportedErr {
+		return nil
+	}
+	return err
 }
@@ -0,0 +1,137 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rowsNode is a sql.Node which produces a fixed set of rows.
+type rowsNode struct {
+	sch  sql.Schema
+	rows []sql.Row
+}
+
+var _ sql.Node = rowsNode{}
+
+func (n rowsNode) Resolved() bool {
+	return true
+}
+
+func (n rowsNode) String() string {
+	return "Rows"
+}
+
+func (n rowsNode) Schema() sql.Schema {
+	return n.sch
+}
+
+func (n rowsNode) Children() []sql.Node {
+	return nil
+}
+
+func (n rowsNode) RowIter(_ *sql.Context) (sql.RowIter, error) {
+	return sql.RowsToRowIter(n.rows...), nil
+}
+
+func (n rowsNode) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(n, len(children), 0)
+	}
+	return n, nil
+}
+
+func TestComparatorNodeDiffer(t *testing.T) {
+	ctx := sql.NewContext(context.Background())
+	sch := sql.Schema{
+		&sql.Column{Name: "name", Type: sql.Text, Nullable: false},
+		&sql.Column{Name: "c0", Type: sql.Int64, Nullable: true},
+	}
+
+	// rows are keyed by their name, ignoring case, and ordered by their lower case names
+	from := rowsNode{sch, []sql.Row{
+		{"alice", int64(1)},
+		{"Bob", int64(2)},
+		{"carol", int64(3)},
+	}}
+	to := rowsNode{sch, []sql.Row{
+		{"Alice", int64(1)},
+		{"bob", int64(5)},
+		{"dave", int64(4)},
+	}}
+	byName := func(l, r sql.Row) (rowCmp, error) {
+		switch strings.Compare(strings.ToLower(l[0].(string)), strings.ToLower(r[0].(string))) {
+		case -1:
+			return lesser, nil
+		case 1:
+			return greater, nil
+		default:
+			return equal, nil
+		}
+	}
+
+	nd, err := newComparatorNodeDiffer(ctx, ctx, from, to, byName)
+	require.NoError(t, err)
+
+	fromNode, toNode := nd.makeFromNode(), nd.makeToNode()
+	assert.Equal(t, sch, fromNode.Schema())
+	assert.Equal(t, sch, toNode.Schema())
+
+	expected := [][2]sql.Row{
+		{{"alice", int64(1)}, {"Alice", int64(1)}},
+		{{"Bob", int64(2)}, {"bob", int64(5)}},
+		{{"carol", int64(3)}, nil},
+		{nil, {"dave", int64(4)}},
+	}
+	assert.Equal(t, expected, readNodeDiffer(t, ctx, fromNode, toNode))
+
+	require.NoError(t, nd.reset())
+	assert.Equal(t, expected, readNodeDiffer(t, ctx, nd.makeFromNode(), nd.makeToNode()))
+}
+
+// readNodeDiffer reads the paired rows of the from and to nodes of a nodeDiffer, as a QueryDiffer does.
+func readNodeDiffer(t *testing.T, ctx *sql.Context, fromNode, toNode sql.Node) [][2]sql.Row {
+	fromIter, err := fromNode.RowIter(ctx)
+	require.NoError(t, err)
+	toIter, err := toNode.RowIter(ctx)
+	require.NoError(t, err)
+
+	var pairs [][2]sql.Row
+	for {
+		from, fromErr := fromIter.Next()
+		if fromErr != nil && fromErr != errSkip && fromErr != io.EOF {
+			require.NoError(t, fromErr)
+		}
+		to, toErr := toIter.Next()
+		if toErr != nil && toErr != errSkip && toErr != io.EOF {
+			require.NoError(t, toErr)
+		}
+		if fromErr == io.EOF && toErr == io.EOF {
+			break
+		}
+		pairs = append(pairs, [2]sql.Row{from, to})
+	}
+
+	require.NoError(t, fromIter.Close())
+	require.NoError(t, toIter.Close())
+	return pairs
+}
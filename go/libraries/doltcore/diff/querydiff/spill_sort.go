@@ -0,0 +1,271 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	// gob registers the basic types of row values, but not the other types which the values of
+	// sql.Types are converted to: the times of DATE, DATETIME and TIMESTAMP values, the decimals
+	// produced by functions of DECIMAL values, and the values of tuple and array expressions
+	gob.Register(time.Time{})
+	gob.Register(decimal.Decimal{})
+	gob.Register([]interface{}{})
+}
+
+// spillSortIter sorts the rows of its child like a plan.Sort node with the same sort fields, while
+// keeping at most about |threshold| bytes of rows in memory. When the buffered rows exceed the
+// threshold they are sorted and written to a temporary file as a run, and the runs are merged as
+// rows are read. Runs are written in a simple format: each row is gob encoded in turn.
+type spillSortIter struct {
+	ctx       *sql.Context
+	fields    []plan.SortField
+	child     sql.RowIter
+	threshold int
+	runs      []*spillRun
+	// mem is the sorted rows which were not spilled, which are merged with the runs
+	mem     []sql.Row
+	started bool
+}
+
+var _ sql.RowIter = &spillSortIter{}
+
+func newSpillSortIter(ctx *sql.Context, fields []plan.SortField, child sql.RowIter, threshold int) *spillSortIter {
+	return &spillSortIter{ctx: ctx, fields: fields, child: child, threshold: threshold}
+}
+
+func (si *spillSortIter) Next() (sql.Row, error) {
+	if !si.started {
+		si.started = true
+		err := si.sortChild()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the row of the earliest run is returned when rows are equal, so that equal rows are returned in
+	// the order of the child, as the in memory rows are the last rows of the child
+	var next *spillRun
+	for _, run := range si.runs {
+		if run.head == nil {
+			continue
+		}
+		if next == nil {
+			next = run
+			continue
+		}
+		cmp, err := CompareRows(si.ctx, si.fields, run.head, next.head)
+		if err != nil {
+			return nil, err
+		}
+		if cmp < 0 {
+			next = run
+		}
+	}
+
+	if len(si.mem) > 0 {
+		if next == nil {
+			r := si.mem[0]
+			si.mem = si.mem[1:]
+			return r, nil
+		}
+		cmp, err := CompareRows(si.ctx, si.fields, si.mem[0], next.head)
+		if err != nil {
+			return nil, err
+		}
+		if cmp < 0 {
+			r := si.mem[0]
+			si.mem = si.mem[1:]
+			return r, nil
+		}
+	}
+
+	if next == nil {
+		return nil, io.EOF
+	}
+
+	r := next.head
+	err := next.advance()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// sortChild reads all of the rows of the child, spilling sorted runs of them to disk whenever the
+// buffered rows exceed the threshold. The rows which remain in memory are sorted.
+func (si *spillSortIter) sortChild() error {
+	var buffered []sql.Row
+	size := 0
+	for {
+		r, err := si.child.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		buffered = append(buffered, r)
+		size += estimateRowSize(r)
+		if size > si.threshold {
+			err = si.sortRows(buffered)
+			if err != nil {
+				return err
+			}
+			run, err := writeSpillRun(buffered)
+			if err != nil {
+				return err
+			}
+			si.runs = append(si.runs, run)
+			buffered, size = nil, 0
+		}
+	}
+
+	err := si.sortRows(buffered)
+	if err != nil {
+		return err
+	}
+	si.mem = buffered
+	return nil
+}
+
+func (si *spillSortIter) sortRows(rows []sql.Row) error {
+	var err error
+	sort.SliceStable(rows, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		var cmp int
+		cmp, err = CompareRows(si.ctx, si.fields, rows[i], rows[j])
+		return cmp < 0
+	})
+	return err
+}
+
+func (si *spillSortIter) Close() error {
+	err := si.child.Close()
+	for _, run := range si.runs {
+		runErr := run.close()
+		if err == nil {
+			err = runErr
+		}
+	}
+	si.runs = nil
+	si.mem = nil
+	return err
+}
+
+// spillRun reads back the sorted rows written to a temporary file by writeSpillRun.
+type spillRun struct {
+	f    *os.File
+	dec  *gob.Decoder
+	head sql.Row
+}
+
+// writeSpillRun writes |rows| to a new temporary file, and returns a spillRun positioned at the
+// first of them. The file is removed when the spillRun is closed.
+func writeSpillRun(rows []sql.Row) (*spillRun, error) {
+	f, err := ioutil.TempFile("", "querydiff-spill-")
+	if err != nil {
+		return nil, err
+	}
+
+	run := &spillRun{f: f}
+	err = run.write(rows)
+	if err != nil {
+		_ = run.close()
+		return nil, err
+	}
+
+	return run, nil
+}
+
+func (run *spillRun) write(rows []sql.Row) error {
+	w := bufio.NewWriter(run.f)
+	enc := gob.NewEncoder(w)
+	for _, r := range rows {
+		err := enc.Encode(r)
+		if err != nil {
+			return fmt.Errorf("error spilling row %v to disk: %s", r, err.Error())
+		}
+	}
+
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+
+	_, err = run.f.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	run.dec = gob.NewDecoder(bufio.NewReader(run.f))
+	return run.advance()
+}
+
+// advance reads the next row of the run into head, which is nil once all rows have been read.
+func (run *spillRun) advance() error {
+	var r sql.Row
+	err := run.dec.Decode(&r)
+	if err == io.EOF {
+		run.head = nil
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	run.head = r
+	return nil
+}
+
+func (run *spillRun) close() error {
+	closeErr := run.f.Close()
+	err := os.Remove(run.f.Name())
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// estimateRowSize estimates the number of bytes of memory used by |r|.
+func estimateRowSize(r sql.Row) int {
+	// each value is an interface of two words
+	size := 24 + 16*len(r)
+	for _, v := range r {
+		switch v := v.(type) {
+		case string:
+			size += len(v)
+		case []byte:
+			size += len(v)
+		default:
+			size += 8
+		}
+	}
+	return size
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpillSortIter(t *testing.T) {
+	ctx := sql.NewContext(context.Background())
+	fields := []plan.SortField{
+		{Column: expression.NewGetField(1, sql.Text, "name", true), Order: plan.Descending, NullOrdering: plan.NullsFirst},
+		{Column: expression.NewGetField(0, sql.Int64, "id", false), Order: plan.Ascending, NullOrdering: plan.NullsFirst},
+	}
+
+	ts := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	var rows []sql.Row
+	for i := 0; i < 200; i++ {
+		var name interface{}
+		if i%7 != 0 {
+			name = fmt.Sprintf("name %d", i%13)
+		}
+		rows = append(rows, sql.Row{
+			int64(i),
+			name,
+			int32(i % 3),
+			float64(i) / 4,
+			ts.Add(time.Duration(i) * time.Hour),
+			decimal.New(int64(i+1), -2),
+			[]interface{}{int64(i), "tuple"},
+		})
+	}
+
+	sortIter := func(threshold int) *spillSortIter {
+		return newSpillSortIter(ctx, fields, sql.RowsToRowIter(rows...), threshold)
+	}
+	readAll := func(iter sql.RowIter) []sql.Row {
+		var actual []sql.Row
+		for {
+			r, err := iter.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			actual = append(actual, r)
+		}
+		return actual
+	}
+
+	// a threshold larger than all of the rows sorts them in memory
+	inMem := sortIter(1 << 30)
+	expected := readAll(inMem)
+	assert.Empty(t, inMem.runs)
+	require.NoError(t, inMem.Close())
+	require.Len(t, expected, len(rows))
+	for i := 1; i < len(expected); i++ {
+		cmp, err := CompareRows(ctx, fields, expected[i-1], expected[i])
+		require.NoError(t, err)
+		assert.True(t, cmp < 0)
+	}
+
+	for _, threshold := range []int{1, 200, 1000, 5000} {
+		t.Run(fmt.Sprintf("threshold %d", threshold), func(t *testing.T) {
+			iter := sortIter(threshold)
+			actual := readAll(iter)
+			assert.NotEmpty(t, iter.runs)
+			assert.Equal(t, expected, actual)
+
+			var files []string
+			for _, run := range iter.runs {
+				files = append(files, run.f.Name())
+			}
+			require.NoError(t, iter.Close())
+			for _, name := range files {
+				_, err := os.Stat(name)
+				assert.True(t, os.IsNotExist(err), "spill file %s was not removed", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"io"
+	"sort"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+)
+
+// tiebreakIter wraps a RowIter sorted by |fields|, such as that of a plan.Sort node, and reorders
+// each run of rows that tie on those fields into a canonical order, comparing the full rows column by column. The
+// order of tied rows produced by a plan.Sort depends on the order in which they were stored, so
+// without this pass the same set of tied rows could be paired up differently in each root and
+// reported as changed.
+type tiebreakIter struct {
+	ctx    *sql.Context
+	sch    sql.Schema
+	norms  []StringNormalization
+	tols   []float64
+	fields []plan.SortField
+	iter   sql.RowIter
+	group  []sql.Row
+	next   sql.Row
+	done   bool
+}
+
+var _ sql.RowIter = &tiebreakIter{}
+
+// newTiebreakIter creates a tiebreakIter. If |norms| or |tols| is non-nil, tied rows are ordered by
+// the normalized values of their string columns and the quantized values of their float columns
+// before their values as they are.
+func newTiebreakIter(ctx *sql.Context, sch sql.Schema, norms []StringNormalization, tols []float64, fields []plan.SortField, iter sql.RowIter) *tiebreakIter {
+	return &tiebreakIter{
+		ctx:    ctx,
+		sch:    sch,
+		norms:  norms,
+		tols:   tols,
+		fields: fields,
+		iter:   iter,
+	}
+}
+
+func (ti *tiebreakIter) Next() (sql.Row, error) {
+	if len(ti.group) == 0 {
+		err := ti.nextGroup()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r := ti.group[0]
+	ti.group = ti.group[1:]
+	return r, nil
+}
+
+// nextGroup reads the next run of tied rows and sorts it.
+func (ti *tiebreakIter) nextGroup() error {
+	if ti.next == nil {
+		if ti.done {
+			return io.EOF
+		}
+
+		r, err := ti.iter.Next()
+		if err == io.EOF {
+			ti.done = true
+			return io.EOF
+		} else if err != nil {
+			return err
+		}
+		ti.next = r
+	}
+
+	group := []sql.Row{ti.next}
+	ti.next = nil
+
+	for !ti.done {
+		r, err := ti.iter.Next()
+		if err == io.EOF {
+			ti.done = true
+			break
+		} else if err != nil {
+			return err
+		}
+
+		cmp, err := compareRows(ti.ctx, ti.ctx, ti.fields, ti.fields, group[0], r)
+		if err != nil {
+			return err
+		}
+		if cmp != 0 {
+			ti.next = r
+			break
+		}
+		group = append(group, r)
+	}
+
+	if len(group) > 1 {
+		var sortErr error
+		sort.SliceStable(group, func(i, j int) bool {
+			cmp, err := compareRowValues(ti.sch, ti.norms, ti.tols, group[i], group[j])
+			if err != nil && sortErr == nil {
+				sortErr = err
+			}
+			return cmp < 0
+		})
+		if sortErr != nil {
+			return sortErr
+		}
+	}
+
+	ti.group = group
+	return nil
+}
+
+func (ti *tiebreakIter) Close() error {
+	return ti.iter.Close()
+}
+
+// compareRowValues compares every column of two rows in schema order, ordering NULLs first. If
+// |norms| or |tols| is non-nil, the rows are compared by the values of comparableRow, and only rows
+// which are equal by those values are compared by their values as they are.
+func compareRowValues(sch sql.Schema, norms []StringNormalization, tols []float64, left, right sql.Row) (int, error) {
+	if norms != nil || tols != nil {
+		cmp, err := compareRowValues(sch, nil, nil, comparableRow(norms, tols, left), comparableRow(norms, tols, right))
+		if err != nil || cmp != 0 {
+			return cmp, err
+		}
+	}
+
+	for i, col := range sch {
+		lv, rv := left[i], right[i]
+
+		if lv == nil && rv == nil {
+			continue
+		} else if lv == nil {
+			return -1, nil
+		} else if rv == nil {
+			return 1, nil
+		}
+
+		cmp, err := col.Type.Compare(lv, rv)
+		if err != nil {
+			return 0, err
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return 0, nil
+}
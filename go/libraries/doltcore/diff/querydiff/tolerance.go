@@ -0,0 +1,155 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package querydiff
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+	"github.com/liquidata-inc/go-mysql-server/sql/plan"
+)
+
+// columnTolerances returns the float tolerance of each column of |sch|, or nil if |tolerances| is
+// empty. Every column named in |tolerances| must exist in |sch|.
+func columnTolerances(sch sql.Schema, tolerances map[string]float64) ([]float64, error) {
+	for name := range tolerances {
+		if columnIndex(sch, name) < 0 {
+			return nil, fmt.Errorf("column '%s' not found", name)
+		}
+	}
+	return schemaTolerances(sch, tolerances), nil
+}
+
+// schemaTolerances returns the float tolerance of each column of |sch|, or nil if |tolerances| is
+// empty. Columns which are not named in |tolerances| have no tolerance.
+func schemaTolerances(sch sql.Schema, tolerances map[string]float64) []float64 {
+	if len(tolerances) == 0 {
+		return nil
+	}
+
+	tols := make([]float64, len(sch))
+	for i, col := range sch {
+		tols[i] = tolerances[strings.ToLower(col.Name)]
+	}
+	return tols
+}
+
+// quantizeSort returns a copy of |sort| which orders the float values of the columns named in
+// |tolerances| by their quantized values, so that rows whose values are equal within the tolerance
+// tie on the sort fields and are ordered by the remaining sort fields. Columns which are not in the
+// schema of |sort|, such as those computed above it, are not quantized.
+func quantizeSort(sort *plan.Sort, tolerances map[string]float64) (*plan.Sort, error) {
+	tols := schemaTolerances(sort.Schema(), tolerances)
+
+	return wrapSortColumns(sort, func(gf *expression.GetField) sql.Expression {
+		if tols[gf.Index()] <= 0 {
+			return gf
+		}
+		return &quantizedFloat{expression.UnaryExpression{Child: gf}, tols[gf.Index()]}
+	})
+}
+
+// quantize returns |v| divided by |epsilon| and rounded to the nearest integer, if |v| is a float
+// value. Float values are equal within a tolerance when they quantize to the same value. Unlike
+// comparing the difference of two values with the tolerance, this is transitive, so the rows of both
+// roots are ordered and matched up consistently. See WithFloatTolerance.
+func quantize(v interface{}, epsilon float64) interface{} {
+	switch f := v.(type) {
+	case float64:
+		return math.Round(f / epsilon)
+	case float32:
+		return float32(math.Round(float64(f) / epsilon))
+	default:
+		return v
+	}
+}
+
+// comparableRow returns a copy of |row| with the normalization of each column of |norms| applied
+// to its string values and the tolerance of each column of |tolerances| applied to its float
+// values. Either may be nil.
+func comparableRow(norms []StringNormalization, tolerances []float64, row sql.Row) sql.Row {
+	if norms != nil {
+		row = normalizeRow(norms, row)
+	}
+	if tolerances != nil {
+		quantized := make(sql.Row, len(row))
+		for i, v := range row {
+			if i < len(tolerances) && tolerances[i] > 0 {
+				v = quantize(v, tolerances[i])
+			}
+			quantized[i] = v
+		}
+		row = quantized
+	}
+	return row
+}
+
+// rowsEqual compares each column of |left| and |right| with its sql.Type, comparing the quantized
+// values of float columns with a tolerance, if |tolerances| is non-nil, and the normalized values
+// of string columns, if |norms| is non-nil. Like Row.Equals, two NULLs are equal, and a nil row is
+// not equal to any other row. Comparison errors name the column and rows which could not be
+// compared.
+func rowsEqual(sch sql.Schema, tolerances []float64, norms []StringNormalization, left, right sql.Row) (bool, error) {
+	if len(left) != len(sch) || len(right) != len(sch) {
+		return false, nil
+	}
+
+	cmpLeft, cmpRight := comparableRow(norms, tolerances, left), comparableRow(norms, tolerances, right)
+	for i, col := range sch {
+		cmp, err := col.Type.Compare(cmpLeft[i], cmpRight[i])
+		if err != nil {
+			return false, fmt.Errorf("error comparing column '%s' of rows %v and %v: %s", col.Name, left, right, err.Error())
+		}
+		if cmp != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// quantizedFloat is an expression which quantizes the float values of its child with an epsilon.
+// Values of other types are returned unchanged.
+type quantizedFloat struct {
+	expression.UnaryExpression
+	epsilon float64
+}
+
+var _ sql.Expression = &quantizedFloat{}
+
+func (qf *quantizedFloat) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	val, err := qf.Child.Eval(ctx, row)
+	if err != nil {
+		return nil, err
+	}
+	return quantize(val, qf.epsilon), nil
+}
+
+func (qf *quantizedFloat) Type() sql.Type {
+	return qf.Child.Type()
+}
+
+func (qf *quantizedFloat) String() string {
+	return fmt.Sprintf("QUANTIZE(%s, %v)", qf.Child, qf.epsilon)
+}
+
+func (qf *quantizedFloat) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(qf, len(children), 1)
+	}
+	return &quantizedFloat{expression.UnaryExpression{Child: children[0]}, qf.epsilon}, nil
+}
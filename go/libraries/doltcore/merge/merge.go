@@ -612,6 +612,13 @@ func MergeCommits(ctx context.Context, ddb *doltdb.DoltDB, commit, mergeCommit *
 		return nil, nil, err
 	}
 
+	return MergeRoots(ctx, ddb, root, mergeRoot, ancRoot)
+}
+
+// MergeRoots merges the tables of |mergeRoot| into |root| using |ancRoot| as the common ancestor. Tables which have
+// conflicts are still written to the returned root along with their conflicts, and can be found by checking the
+// Conflicts field of their MergeStats.
+func MergeRoots(ctx context.Context, ddb *doltdb.DoltDB, root, mergeRoot, ancRoot *doltdb.RootValue) (*doltdb.RootValue, map[string]*MergeStats, error) {
 	merger := NewMerger(ctx, root, mergeRoot, ancRoot, ddb.ValueReadWriter())
 
 	tblNames, err := doltdb.UnionTableNames(ctx, root, mergeRoot)
@@ -0,0 +1,183 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dfunctions
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/liquidata-inc/go-mysql-server/sql/expression"
+)
+
+const MergeFuncName = "merge"
+
+type MergeFunc struct {
+	expression.UnaryExpression
+}
+
+// NewMergeFunc creates a new MergeFunc expression.
+func NewMergeFunc(e sql.Expression) sql.Expression {
+	return &MergeFunc{expression.UnaryExpression{Child: e}}
+}
+
+// Eval implements the Expression interface. The named branch is merged into the session's working root and the merged
+// root is written as a new commit whose parents are the session's head commit and the head of the branch. The hash of
+// the new commit is returned. If any table has conflicts an error listing the conflicted tables is returned instead.
+func (mf *MergeFunc) Eval(ctx *sql.Context, row sql.Row) (interface{}, error) {
+	val, err := mf.Child.Eval(ctx, row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if val == nil {
+		return nil, nil
+	}
+
+	branchName, ok := val.(string)
+
+	if !ok {
+		return nil, errors.New("branch name is not a string")
+	}
+
+	dbName := ctx.GetCurrentDatabase()
+	dSess := sqle.DSessFromSess(ctx.Session)
+	parent, err := dSess.GetParentCommit(ctx, dbName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	root, ok := dSess.GetRoot(dbName)
+
+	if !ok {
+		return nil, fmt.Errorf("unknown database '%s'", dbName)
+	}
+
+	ddb, ok := dSess.GetDoltDB(dbName)
+
+	if !ok {
+		return nil, sql.ErrDatabaseNotFound.New(dbName)
+	}
+
+	cs, err := doltdb.NewCommitSpec(branchName, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	mergeCommit, err := ddb.Resolve(ctx, cs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ancCommit, err := doltdb.GetCommitAncestor(ctx, parent, mergeCommit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mergeRoot, err := mergeCommit.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ancRoot, err := ancCommit.GetRootValue()
+
+	if err != nil {
+		return nil, err
+	}
+
+	mergedRoot, tblToStats, err := merge.MergeRoots(ctx, ddb, root, mergeRoot, ancRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicted []string
+	for tblName, stats := range tblToStats {
+		if stats.Conflicts > 0 {
+			conflicted = append(conflicted, tblName)
+		}
+	}
+
+	if len(conflicted) > 0 {
+		sort.Strings(conflicted)
+		return nil, fmt.Errorf("merge of '%s' has conflicts in tables: %s", branchName, strings.Join(conflicted, ", "))
+	}
+
+	h, err := ddb.WriteRootValue(ctx, mergedRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if dSess.Username == "" || dSess.Email == "" {
+		return nil, errors.New("merge function failure: Username and/or email not configured")
+	}
+
+	meta, err := doltdb.NewCommitMeta(dSess.Username, dSess.Email, fmt.Sprintf("Merge branch '%s'", branchName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err := ddb.WriteCommitDanglingCommit(ctx, h, []*doltdb.Commit{parent, mergeCommit}, meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	h, err = cm.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return h.String(), nil
+}
+
+// String implements the Stringer interface.
+func (mf *MergeFunc) String() string {
+	return fmt.Sprintf("MERGE(%s)", mf.Child.String())
+}
+
+// IsNullable implements the Expression interface.
+func (mf *MergeFunc) IsNullable() bool {
+	return mf.Child.IsNullable()
+}
+
+// WithChildren implements the Expression interface.
+func (mf *MergeFunc) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(mf, len(children), 1)
+	}
+
+	return NewMergeFunc(children[0]), nil
+}
+
+// Type implements the Expression interface.
+func (mf *MergeFunc) Type() sql.Type {
+	return sql.Text
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlutil
+
+import (
+	"io"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+)
+
+const (
+	lookaheadBufferSize = 1024
+)
+
+// LookaheadRowIter wraps a sql.RowIter with one row of lookahead. Once started, rows are read from the
+// wrapped iterator in a background goroutine and buffered, so the next row can be inspected with Peek
+// before it is consumed with Pop. Errors from the wrapped iterator are recorded in the AtomicError
+// given to NewLookaheadRowIter, after which the LookaheadRowIter behaves as if it were exhausted.
+type LookaheadRowIter struct {
+	currRow sql.Row
+	iter    sql.RowIter
+	rowChan chan sql.Row
+	started bool
+	closed  bool
+	ae      *atomicerr.AtomicError
+}
+
+// NewLookaheadRowIter creates a LookaheadRowIter over |iter|. Errors from |iter| are recorded in |ae|.
+func NewLookaheadRowIter(iter sql.RowIter, ae *atomicerr.AtomicError) *LookaheadRowIter {
+	return &LookaheadRowIter{
+		iter:    iter,
+		rowChan: make(chan sql.Row, lookaheadBufferSize),
+		ae:      ae,
+	}
+}
+
+// MaybeStart starts reading from the wrapped iterator if it has not been started already, and
+// blocks until the first row is available.
+func (li *LookaheadRowIter) MaybeStart() {
+	if li.started {
+		return
+	}
+
+	go func() {
+		defer close(li.rowChan)
+		for {
+			r, err := li.iter.Next()
+			if r != nil {
+				li.rowChan <- r
+			}
+			if err != nil {
+				if err != io.EOF {
+					li.ae.SetIfError(err)
+				}
+				break
+			}
+		}
+	}()
+	li.currRow = <-li.rowChan
+	li.started = true
+}
+
+// Peek returns the next row without consuming it, or nil if there are no more rows.
+func (li *LookaheadRowIter) Peek() sql.Row {
+	return li.currRow
+}
+
+// Pop consumes and returns the next row, or nil if there are no more rows.
+func (li *LookaheadRowIter) Pop() sql.Row {
+	r := li.currRow
+	li.currRow = <-li.rowChan
+	return r
+}
+
+// IsDone returns whether all rows have been consumed.
+func (li *LookaheadRowIter) IsDone() bool {
+	return li.Peek() == nil
+}
+
+// Close closes the wrapped iterator, recording any error in the AtomicError, and discards any
+// buffered rows. Calling Close more than once has no effect.
+func (li *LookaheadRowIter) Close() {
+	if li.closed {
+		return
+	}
+	li.closed = true
+
+	li.ae.SetIfError(li.iter.Close())
+	if !li.started {
+		return
+	}
+
+	open := true
+	for open {
+		_, open = <-li.rowChan
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlutil
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/liquidata-inc/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+)
+
+// errRowIter returns its rows followed by err.
+type errRowIter struct {
+	rows     []sql.Row
+	err      error
+	closeErr error
+	closed   bool
+}
+
+func (ei *errRowIter) Next() (sql.Row, error) {
+	if len(ei.rows) == 0 {
+		return nil, ei.err
+	}
+	r := ei.rows[0]
+	ei.rows = ei.rows[1:]
+	return r, nil
+}
+
+func (ei *errRowIter) Close() error {
+	ei.closed = true
+	return ei.closeErr
+}
+
+func TestLookaheadRowIter(t *testing.T) {
+	rows := []sql.Row{
+		sql.NewRow(int64(0), "zero"),
+		sql.NewRow(int64(1), "one"),
+		sql.NewRow(int64(2), "two"),
+	}
+
+	ae := atomicerr.New()
+	li := NewLookaheadRowIter(sql.RowsToRowIter(rows...), ae)
+	li.MaybeStart()
+	li.MaybeStart()
+
+	for _, r := range rows {
+		require.False(t, li.IsDone())
+		assert.Equal(t, r, li.Peek())
+		assert.Equal(t, r, li.Peek())
+		assert.Equal(t, r, li.Pop())
+	}
+
+	assert.True(t, li.IsDone())
+	assert.Nil(t, li.Peek())
+	assert.Nil(t, li.Pop())
+
+	li.Close()
+	li.Close()
+	assert.NoError(t, ae.Get())
+}
+
+func TestLookaheadRowIterEmpty(t *testing.T) {
+	ae := atomicerr.New()
+	li := NewLookaheadRowIter(sql.RowsToRowIter(), ae)
+	li.MaybeStart()
+	assert.True(t, li.IsDone())
+	li.Close()
+	assert.NoError(t, ae.Get())
+}
+
+func TestLookaheadRowIterErrors(t *testing.T) {
+	iterErr := errors.New("iter error")
+	ae := atomicerr.New()
+	iter := &errRowIter{rows: []sql.Row{sql.NewRow(int64(0))}, err: iterErr}
+	li := NewLookaheadRowIter(iter, ae)
+	li.MaybeStart()
+
+	assert.Equal(t, sql.NewRow(int64(0)), li.Pop())
+	assert.True(t, li.IsDone())
+	assert.Equal(t, iterErr, ae.Get())
+
+	li.Close()
+	assert.True(t, iter.closed)
+
+	// io.EOF ends iteration without recording an error, but errors from Close are recorded
+	closeErr := errors.New("close error")
+	ae = atomicerr.New()
+	iter = &errRowIter{err: io.EOF, closeErr: closeErr}
+	li = NewLookaheadRowIter(iter, ae)
+	li.MaybeStart()
+	assert.True(t, li.IsDone())
+	assert.NoError(t, ae.Get())
+	li.Close()
+	assert.Equal(t, closeErr, ae.Get())
+
+	// closing before starting does not read from the wrapped iterator
+	ae = atomicerr.New()
+	iter = &errRowIter{rows: []sql.Row{sql.NewRow(int64(0))}, err: io.EOF}
+	li = NewLookaheadRowIter(iter, ae)
+	li.Close()
+	assert.True(t, iter.closed)
+	assert.Len(t, iter.rows, 1)
+	assert.NoError(t, ae.Get())
+}
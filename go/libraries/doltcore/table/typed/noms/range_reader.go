@@ -27,6 +27,35 @@ import (
 // being read is in the range]
 type InRangeCheck func(tuple types.Tuple) (bool, error)
 
+// LessFunc reports whether |a| is ordered before |b|.
+type LessFunc func(a, b types.Value) (bool, error)
+
+// NomsLess returns a LessFunc which uses the noms ordering of Value.Less. This is the order in which keys are
+// physically stored in a map.
+func NomsLess(nbf *types.NomsBinFormat) LessFunc {
+	return func(a, b types.Value) (bool, error) {
+		return a.Less(nbf, b)
+	}
+}
+
+// NewLessThanCheck returns an InRangeCheck which reports keys as in range while |less| orders them before |end|.
+// This allows range bounds to be checked using an ordering other than the noms ordering, such as a SQL collation.
+// Keys are still read in the order they are stored in the map, so |less| only controls where a range terminates,
+// and it must order the keys consistently with the direction the range is read for the range to end where expected.
+func NewLessThanCheck(end types.Tuple, less LessFunc) InRangeCheck {
+	return func(tuple types.Tuple) (bool, error) {
+		return less(tuple, end)
+	}
+}
+
+// NewGreaterThanCheck returns an InRangeCheck which reports keys as in range while |less| orders |end| before them.
+// The same caveats as NewLessThanCheck apply.
+func NewGreaterThanCheck(end types.Tuple, less LessFunc) InRangeCheck {
+	return func(tuple types.Tuple) (bool, error) {
+		return less(end, tuple)
+	}
+}
+
 // ReadRange represents a range of values to be read
 type ReadRange struct {
 	// Start is a Dolt map key which is the starting point (or ending point if Reverse is true)
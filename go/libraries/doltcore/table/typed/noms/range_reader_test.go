@@ -98,6 +98,26 @@ func TestRangeReader(t *testing.T) {
 			[]int64{100},
 		},
 
+		{
+			"test range starting at with noms less check",
+			[]*ReadRange{NewRangeStartingAt(mustTuple(10), NewLessThanCheck(mustTuple(20), NomsLess(types.Format_Default)))},
+			[]int64{10, 12, 14, 16, 18},
+		},
+		{
+			"test range ending at with noms greater check",
+			[]*ReadRange{NewRangeEndingAt(mustTuple(10), NewGreaterThanCheck(mustTuple(2), NomsLess(types.Format_Default)))},
+			[]int64{10, 8, 6, 4},
+		},
+		{
+			"test range ending at with reverse less check",
+			[]*ReadRange{NewRangeEndingAt(mustTuple(10), NewLessThanCheck(mustTuple(2), reverseLess))},
+			[]int64{10, 8, 6, 4},
+		},
+		{
+			"test range starting at with reverse greater check",
+			[]*ReadRange{NewRangeStartingAt(mustTuple(10), NewGreaterThanCheck(mustTuple(20), reverseLess))},
+			[]int64{10, 12, 14, 16, 18},
+		},
 		{
 			"test multiple ranges",
 			[]*ReadRange{
@@ -159,3 +179,7 @@ func lessThanCheck(n int64) func(k types.Tuple) (bool, error) {
 		return int64(col0.(types.Int)) < n, nil
 	}
 }
+
+func reverseLess(a, b types.Value) (bool, error) {
+	return b.Less(types.Format_Default, a)
+}
@@ -89,6 +89,45 @@ func NewMap(ctx context.Context, vrw ValueReadWriter, kv ...Value) (Map, error)
 	return newMap(seq.(orderedSequence)), nil
 }
 
+// NewMapFromSortedTuples creates a Map from key/value pairs which are already sorted in ascending key order. The
+// pairs are appended directly to the map's sequence chunker, avoiding the sorting and editing done by NewMap and
+// MapEditor. ErrKeysNotOrdered is returned if the keys are not strictly ascending.
+func NewMapFromSortedTuples(ctx context.Context, vrw ValueReadWriter, pairs []struct{ K, V Value }) (Map, error) {
+	ch, err := newEmptyMapSequenceChunker(ctx, vrw)
+
+	if err != nil {
+		return EmptyMap, err
+	}
+
+	for i, pair := range pairs {
+		if i > 0 {
+			isLess, err := pairs[i-1].K.Less(vrw.Format(), pair.K)
+
+			if err != nil {
+				return EmptyMap, err
+			}
+
+			if !isLess {
+				return EmptyMap, ErrKeysNotOrdered
+			}
+		}
+
+		_, err := ch.Append(ctx, mapEntry{key: pair.K, value: pair.V})
+
+		if err != nil {
+			return EmptyMap, err
+		}
+	}
+
+	seq, err := ch.Done(ctx)
+
+	if err != nil {
+		return EmptyMap, err
+	}
+
+	return newMap(seq.(orderedSequence)), nil
+}
+
 // NewStreamingMap takes an input channel of values and returns a output
 // channel that will produce a finished Map. Values sent to the input channel
 // must be alternating keys and values. (e.g. k1, v1, k2, v2...). Moreover keys
@@ -192,6 +231,107 @@ func (m Map) DiffLeftRight(ctx context.Context, last Map, ae *atomicerr.AtomicEr
 	orderedSequenceDiffLeftRight(ctx, last.orderedSequence, m.orderedSequence, ae, changes, closeChan)
 }
 
+// StreamDiff sends the diff from |last| to |m| to |changes| using the top-down algorithm, like Diff, so subtrees
+// shared by both maps are skipped without being read. Unlike Diff, it returns any error encountered instead of setting
+// it on an AtomicError, and it stops sending changes and returns ctx.Err() if |ctx| is canceled before the diff is
+// complete. |changes| is not closed when StreamDiff returns.
+func (m Map) StreamDiff(ctx context.Context, last Map, changes chan<- ValueChanged) error {
+	if m.Equals(last) {
+		return nil
+	}
+
+	ae := atomicerr.New()
+	completed := orderedSequenceDiffTopDown(ctx, last.orderedSequence, m.orderedSequence, ae, changes, ctx.Done())
+
+	if err := ae.Get(); err != nil {
+		return err
+	}
+
+	if !completed {
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// UpdateFrom returns a copy of m updated with the entries of overlay. Keys of overlay which are in m have their values
+// replaced, and keys which are not in m are added. By convention, a key whose value in overlay is NullValue is a
+// tombstone, and is removed from the result, so NullValue itself cannot be written to m with UpdateFrom. The entries of
+// overlay are streamed to ApplyEdits in order, rather than being added to an editor and sorted again.
+func (m Map) UpdateFrom(ctx context.Context, overlay Map) (Map, error) {
+	itr, err := overlay.Iterator(ctx)
+
+	if err != nil {
+		return EmptyMap, err
+	}
+
+	m, _, err = ApplyEdits(ctx, &mapOverlayEditProvider{ctx, itr, int64(overlay.Len())}, m)
+	return m, err
+}
+
+// mapOverlayEditProvider provides the entries of a map, in order, as edits for ApplyEdits. Entries whose value is
+// NullValue are provided as removals.
+type mapOverlayEditProvider struct {
+	ctx      context.Context
+	itr      MapIterator
+	numEdits int64
+}
+
+func (ep *mapOverlayEditProvider) Next() (*KVP, error) {
+	k, v, err := ep.itr.Next(ep.ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if k == nil {
+		return nil, nil
+	}
+
+	if IsNull(v) {
+		return &KVP{Key: k}, nil
+	}
+
+	return &KVP{Key: k, Val: v}, nil
+}
+
+func (ep *mapOverlayEditProvider) NumEdits() int64 {
+	return ep.numEdits
+}
+
+// DiffKeys returns the keys which differ between |last| and |m|, classified like the changes of Diff: added keys are
+// only in m, removed keys are only in last, and changed keys are in both with different values. Keys are returned in
+// ascending order. The diff is computed top-down, so subtrees shared by both maps are skipped without being read.
+func (m Map) DiffKeys(ctx context.Context, last Map) (added, removed, changed []Value, err error) {
+	if m.Equals(last) {
+		return nil, nil, nil, nil
+	}
+
+	ae := atomicerr.New()
+	changes := make(chan ValueChanged)
+	go func() {
+		defer close(changes)
+		m.Diff(ctx, last, ae, changes, nil)
+	}()
+
+	for change := range changes {
+		switch change.ChangeType {
+		case DiffChangeAdded:
+			added = append(added, change.Key)
+		case DiffChangeRemoved:
+			removed = append(removed, change.Key)
+		case DiffChangeModified:
+			changed = append(changed, change.Key)
+		}
+	}
+
+	if err := ae.Get(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return added, removed, changed, nil
+}
+
 // Collection interface
 
 func (m Map) asSequence() sequence {
@@ -244,6 +384,204 @@ func (m Map) Last(ctx context.Context) (Value, Value, error) {
 	return m.firstOrLast(ctx, true)
 }
 
+// FirstKey returns the smallest key in the map, or nil if the map is empty. The map's value for the key is not decoded.
+func (m Map) FirstKey(ctx context.Context) (Value, error) {
+	return m.firstOrLastKey(ctx, false)
+}
+
+// LastKey returns the largest key in the map, or nil if the map is empty. The map's value for the key is not decoded.
+func (m Map) LastKey(ctx context.Context) (Value, error) {
+	return m.firstOrLastKey(ctx, true)
+}
+
+func (m Map) firstOrLastKey(ctx context.Context, last bool) (Value, error) {
+	if m.Empty() {
+		return nil, nil
+	}
+
+	cur, err := newCursorAt(ctx, m.orderedSequence, emptyKey, false, last)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !cur.valid() {
+		return nil, nil
+	}
+
+	key, err := getCurrentKey(cur)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return key.v, nil
+}
+
+// Sample returns up to n keys spread evenly across the map in ascending order. Rather than scanning the map, each key
+// is found by seeking directly to its index, and the first and last keys of the map are always included when n > 1.
+// If the map has n or fewer entries all of its keys are returned.
+func (m Map) Sample(ctx context.Context, n int) ([]Value, error) {
+	l := m.Len()
+
+	if n <= 0 || l == 0 {
+		return nil, nil
+	}
+
+	if uint64(n) > l {
+		n = int(l)
+	}
+
+	keys := make([]Value, n)
+	for i := 0; i < n; i++ {
+		var idx uint64
+		if n > 1 {
+			idx = uint64(i) * (l - 1) / uint64(n-1)
+		}
+
+		cur, err := newCursorAtIndex(ctx, m.orderedSequence, idx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := getCurrentKey(cur)
+
+		if err != nil {
+			return nil, err
+		}
+
+		keys[i] = key.v
+	}
+
+	return keys, nil
+}
+
+// SplitPoints returns up to n-1 keys of the map, in ascending order, which split it into at most n ordered segments of
+// roughly equal size, so that the segments can be scanned in parallel. The first segment holds the keys less than the
+// first split key, each following segment starts at a split key and holds the keys less than the next one, and the
+// last segment holds the remaining keys. Every segment has at least one key. Where a segment boundary falls within a
+// leaf chunk of the map, the first key of the chunk is used as the split key if it is after the previous split key, so
+// that segments share as few chunks as possible.
+func (m Map) SplitPoints(ctx context.Context, n int) ([]Value, error) {
+	l := m.Len()
+
+	if n <= 1 || l == 0 {
+		return nil, nil
+	}
+
+	if uint64(n) > l {
+		n = int(l)
+	}
+
+	// Segments must be non-empty, so every split key has to be after the first key of the map.
+	first, err := newCursorAtIndex(ctx, m.orderedSequence, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := getCurrentKey(first)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nbf := m.format()
+	var splits []Value
+	for i := 1; i < n; i++ {
+		cur, err := newCursorAtIndex(ctx, m.orderedSequence, uint64(i)*l/uint64(n))
+
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := getCurrentKey(cur)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if cur.idx > 0 {
+			chunkStart, err := cur.seq.(orderedSequence).getKey(0)
+
+			if err != nil {
+				return nil, err
+			}
+
+			isAligned, err := prev.Less(nbf, chunkStart)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if isAligned {
+				key = chunkStart
+			}
+		}
+
+		isAfter, err := prev.Less(nbf, key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !isAfter {
+			continue
+		}
+
+		splits = append(splits, key.v)
+		prev = key
+	}
+
+	return splits, nil
+}
+
+// Keys returns up to limit keys of the map in ascending order. If limit is not positive, all keys are returned.
+func (m Map) Keys(ctx context.Context, limit int) ([]Value, error) {
+	return m.collect(ctx, limit, true)
+}
+
+// Values returns up to limit values of the map, in the order of their keys. If limit is not positive, all values are
+// returned.
+func (m Map) Values(ctx context.Context, limit int) ([]Value, error) {
+	return m.collect(ctx, limit, false)
+}
+
+func (m Map) collect(ctx context.Context, limit int, keys bool) ([]Value, error) {
+	n := m.Len()
+	if limit > 0 && uint64(limit) < n {
+		n = uint64(limit)
+	}
+
+	itr, err := m.Iterator(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vals := make([]Value, 0, n)
+	for uint64(len(vals)) < n {
+		k, v, err := itr.Next(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if k == nil {
+			break
+		}
+
+		if keys {
+			vals = append(vals, k)
+		} else {
+			vals = append(vals, v)
+		}
+	}
+
+	return vals, nil
+}
+
 func (m Map) At(ctx context.Context, idx uint64) (key, value Value, err error) {
 	if idx >= m.Len() {
 		panic(fmt.Errorf("out of bounds: %d >= %d", idx, m.Len()))
@@ -314,6 +652,96 @@ func (m Map) Has(ctx context.Context, key Value) (bool, error) {
 
 type mapIterCallback func(key, value Value) (stop bool, err error)
 
+// EqualsRange returns whether m and other contain the same entries with keys in the range [start, end). Entries
+// outside of the range are not compared. Both maps are iterated in lockstep from start, and comparison stops at the
+// first difference in the range.
+func (m Map) EqualsRange(ctx context.Context, other Map, start, end Value) (bool, error) {
+	if m.Equals(other) {
+		return true, nil
+	}
+
+	nbf := m.Format()
+	itr, err := m.IteratorFrom(ctx, start)
+
+	if err != nil {
+		return false, err
+	}
+
+	otherItr, err := other.IteratorFrom(ctx, start)
+
+	if err != nil {
+		return false, err
+	}
+
+	for {
+		k, v, err := nextInRange(ctx, nbf, itr, end)
+
+		if err != nil {
+			return false, err
+		}
+
+		otherK, otherV, err := nextInRange(ctx, nbf, otherItr, end)
+
+		if err != nil {
+			return false, err
+		}
+
+		if k == nil || otherK == nil {
+			return k == nil && otherK == nil, nil
+		}
+
+		if !k.Equals(otherK) || !v.Equals(otherV) {
+			return false, nil
+		}
+	}
+}
+
+// nextInRange returns the next entry of itr, or nils once itr is exhausted or its next key is not less than end.
+func nextInRange(ctx context.Context, nbf *NomsBinFormat, itr MapIterator, end Value) (Value, Value, error) {
+	k, v, err := itr.Next(ctx)
+
+	if err != nil || k == nil {
+		return nil, nil, err
+	}
+
+	isLess, err := k.Less(nbf, end)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !isLess {
+		return nil, nil, nil
+	}
+
+	return k, v, nil
+}
+
+// ContainsRange returns whether m contains at least one key in the range [start, end). Only the first key at or after
+// start is read. An inverted or empty range contains no keys.
+func (m Map) ContainsRange(ctx context.Context, start, end Value) (bool, error) {
+	nbf := m.Format()
+	ok, err := start.Less(nbf, end)
+
+	if err != nil || !ok {
+		return false, err
+	}
+
+	itr, err := m.IteratorFrom(ctx, start)
+
+	if err != nil {
+		return false, err
+	}
+
+	k, _, err := itr.Next(ctx)
+
+	if err != nil || k == nil {
+		return false, err
+	}
+
+	return k.Less(nbf, end)
+}
+
 func (m Map) Iter(ctx context.Context, cb mapIterCallback) error {
 	cur, err := newCursorAt(ctx, m.orderedSequence, emptyKey, false, false)
 
@@ -386,6 +814,34 @@ func (m Map) IteratorFrom(ctx context.Context, key Value) (MapIterator, error) {
 	return &mapIterator{sequenceIter: cur}, nil
 }
 
+// IteratorAfter returns an iterator starting at the first key strictly greater than |key|. Unlike IteratorFrom, an
+// entry whose key equals |key| is skipped, making it suitable for paging through a map by passing the last key seen.
+func (m Map) IteratorAfter(ctx context.Context, key Value) (MapIterator, error) {
+	cur, err := newCursorAtValue(ctx, m.orderedSequence, key, false, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cur.valid() {
+		item, err := cur.current()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if item.(mapEntry).key.Equals(key) {
+			_, err := cur.advance(ctx)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &mapIterator{sequenceIter: cur}, nil
+}
+
 func (m Map) IteratorBackFrom(ctx context.Context, key Value) (MapIterator, error) {
 	cur, err := newCursorAtValue(ctx, m.orderedSequence, key, false, false)
 
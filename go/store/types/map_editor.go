@@ -53,25 +53,78 @@ type EditAccumulator interface {
 
 // MapEditor allows for efficient editing of Map-typed prolly trees.
 type MapEditor struct {
-	m        Map
-	numEdits int64
-	acc      EditAccumulator
+	m         Map
+	numEdits  int64
+	acc       EditAccumulator
+	ranges    []mapKeyRange
+	batchSize int64
+	pending   int64
+	err       error
+}
+
+// mapKeyRange is a range of keys from start, inclusive, to end, exclusive.
+type mapKeyRange struct {
+	start Value
+	end   Value
 }
 
 func NewMapEditor(m Map) *MapEditor {
-	return &MapEditor{m, 0, CreateEditAccForMapEdits(m.format())}
+	return &MapEditor{m: m, acc: CreateEditAccForMapEdits(m.format())}
+}
+
+// SetBatchSize sets the number of edits which are accumulated before they are applied to the Map being edited. The
+// default batch size is 0, which accumulates every edit until Map is called. Applying edits in batches bounds the
+// memory used to accumulate them, at the cost of rewriting the chunks of the Map which are edited by more than one
+// batch. The batch size does not change the resulting Map, as edits are applied in the order in which they were
+// added. An error applying a batch is returned by Map.
+func (med *MapEditor) SetBatchSize(n int) {
+	med.batchSize = int64(n)
 }
 
 // Map applies all edits and returns a newly updated Map
 func (med *MapEditor) Map(ctx context.Context) (Map, error) {
-	edits, err := med.acc.FinishedEditing()
+	if med.err != nil {
+		return EmptyMap, med.err
+	}
+
+	err := med.flush(ctx)
 
 	if err != nil {
 		return EmptyMap, err
 	}
 
-	m, _, err := ApplyEdits(ctx, edits, med.m)
-	return m, err
+	return med.m, nil
+}
+
+// flush applies the pending range removals and edits to the Map being edited, and starts a new batch of edits. The
+// range removals are applied first, as RemoveRange flushes the edits added before it.
+func (med *MapEditor) flush(ctx context.Context) error {
+	edits, err := med.acc.FinishedEditing()
+
+	if err != nil {
+		return err
+	}
+
+	m := med.m
+	for _, r := range med.ranges {
+		m, err = removeMapRange(ctx, m, r.start, r.end)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	m, _, err = ApplyEdits(ctx, edits, m)
+
+	if err != nil {
+		return err
+	}
+
+	med.m = m
+	med.acc = CreateEditAccForMapEdits(m.format())
+	med.ranges = nil
+	med.pending = 0
+	return nil
 }
 
 // Set adds an edit
@@ -97,9 +150,41 @@ func (med *MapEditor) Remove(k LesserValuable) *MapEditor {
 	return med
 }
 
+// RemoveRange adds an edit that will remove every key greater than or equal to start and less than end. Edits are
+// applied in the order in which they were added, so a key within the range that was Set before RemoveRange was called
+// is removed, and one that is Set afterward is present in the resulting Map. To keep that order, the edits added before
+// the range removal are applied to the Map being edited when it is added.
+func (med *MapEditor) RemoveRange(start, end Value) *MapEditor {
+	d.PanicIfTrue(start == nil || end == nil)
+	med.numEdits++
+
+	if med.err != nil {
+		return med
+	}
+
+	if med.pending > 0 {
+		// edits are added without a context, so the earlier edits are applied without one
+		med.err = med.flush(context.Background())
+	}
+
+	med.ranges = append(med.ranges, mapKeyRange{start, end})
+	return med
+}
+
 func (med *MapEditor) set(k LesserValuable, v Valuable) {
 	med.numEdits++
+
+	if med.err != nil {
+		return
+	}
+
 	med.acc.AddEdit(k, v)
+	med.pending++
+
+	if med.batchSize > 0 && med.pending >= med.batchSize {
+		// edits are added without a context, so the batch is applied without one
+		med.err = med.flush(context.Background())
+	}
 }
 
 // NumEdits returns the number of edits that have been added.
@@ -110,3 +195,72 @@ func (med *MapEditor) NumEdits() int64 {
 func (med *MapEditor) Format() *NomsBinFormat {
 	return med.m.format()
 }
+
+// removeMapRange returns a copy of m without the keys in the range [start, end). A cursor is positioned at start and
+// the chunker skips the entries in the range, so only the chunks spanning the range are rewritten.
+func removeMapRange(ctx context.Context, m Map, start, end Value) (Map, error) {
+	if m.Empty() {
+		return m, nil
+	}
+
+	nbf := m.format()
+	endKey, err := newOrderedKey(end, nbf)
+
+	if err != nil {
+		return EmptyMap, err
+	}
+
+	seq := m.orderedSequence
+	cur, err := newCursorAtValue(ctx, seq, start, true, false)
+
+	if err != nil {
+		return EmptyMap, err
+	}
+
+	vrw := seq.valueReadWriter()
+
+	var ch *sequenceChunker
+	for cur.valid() {
+		key, err := getCurrentKey(cur)
+
+		if err != nil {
+			return EmptyMap, err
+		}
+
+		inRange, err := key.Less(nbf, endKey)
+
+		if err != nil {
+			return EmptyMap, err
+		}
+
+		if !inRange {
+			break
+		}
+
+		if ch == nil {
+			ch, err = newSequenceChunker(ctx, cur, 0, vrw, makeMapLeafChunkFn(vrw), newOrderedMetaSequenceChunkFn(MapKind, vrw), mapHashValueBytes)
+
+			if err != nil {
+				return EmptyMap, err
+			}
+		}
+
+		err = ch.Skip(ctx)
+
+		if err != nil {
+			return EmptyMap, err
+		}
+	}
+
+	if ch == nil {
+		return m, nil
+	}
+
+	newSeq, err := ch.Done(ctx)
+
+	if err != nil {
+		return EmptyMap, err
+	}
+
+	return newMap(newSeq.(orderedSequence)), nil
+}
@@ -21,18 +21,34 @@
 
 package types
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrSeekBeforePosition = errors.New("cannot seek map iterator to a key before its current position")
+var ErrSeekReverseIterator = errors.New("cannot seek a reverse map iterator")
 
 // MapIterator is the interface used by iterators over Noms Maps.
 type MapIterator interface {
 	Next(ctx context.Context) (k, v Value, err error)
 }
 
+// SeekableMapIterator is a MapIterator that can be moved forward to a key without visiting the entries in between.
+type SeekableMapIterator interface {
+	MapIterator
+	SeekTo(ctx context.Context, key Value) error
+}
+
 // mapIterator can efficiently iterate through a Noms Map.
 type mapIterator struct {
 	sequenceIter sequenceIterator
 	currentKey   Value
 	currentValue Value
+	// seekPos is the last key returned by Next() or passed to SeekTo(). Seeking to a key before it is an error.
+	seekPos Value
 }
 
 // Next returns the subsequent entries from the Map, starting with the entry at which the iterator
@@ -47,6 +63,7 @@ func (mi *mapIterator) Next(ctx context.Context) (k, v Value, err error) {
 
 		entry := item.(mapEntry)
 		mi.currentKey, mi.currentValue = entry.key, entry.value
+		mi.seekPos = entry.key
 		_, err = mi.sequenceIter.advance(ctx)
 
 		if err != nil {
@@ -58,3 +75,301 @@ func (mi *mapIterator) Next(ctx context.Context) (k, v Value, err error) {
 
 	return mi.currentKey, mi.currentValue, nil
 }
+
+// SeekTo advances the iterator so that the next call to Next() returns the first entry whose key is >= |key|. Seeking
+// to a key before the last key returned by Next() or passed to SeekTo() returns ErrSeekBeforePosition, while seeking
+// to a key between that position and the next entry is a no-op. Seeking past the last key leaves the iterator
+// exhausted.
+func (mi *mapIterator) SeekTo(ctx context.Context, key Value) error {
+	if !mi.sequenceIter.valid() {
+		return nil
+	}
+
+	switch cur := mi.sequenceIter.(type) {
+	case *sequenceCursor:
+		if cur.reverse {
+			return ErrSeekReverseIterator
+		}
+
+		err := mi.checkSeekKey(cur.seq.format(), key)
+
+		if err != nil {
+			return err
+		}
+
+		seekKey, err := newOrderedKey(key, cur.seq.format())
+
+		if err != nil {
+			return err
+		}
+
+		_, err = seekCursorForward(ctx, cur, seekKey)
+		return err
+
+	case *bufferedSequenceIterator:
+		err := mi.checkSeekKey(cur.seq.format(), key)
+
+		if err != nil {
+			return err
+		}
+
+		// buffered iterators read composite chunks so entries are skipped one at a time.
+		for cur.valid() {
+			item, err := cur.current()
+
+			if err != nil {
+				return err
+			}
+
+			isLess, err := item.(mapEntry).key.Less(cur.seq.format(), key)
+
+			if err != nil {
+				return err
+			}
+
+			if !isLess {
+				break
+			}
+
+			_, err = cur.advance(ctx)
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	panic("unknown sequence iterator type")
+}
+
+// checkSeekKey returns ErrSeekBeforePosition if |seekKey| is before the iterator's position, and otherwise moves the
+// position to |seekKey|.
+func (mi *mapIterator) checkSeekKey(nbf *NomsBinFormat, seekKey Value) error {
+	if mi.seekPos != nil {
+		isLess, err := seekKey.Less(nbf, mi.seekPos)
+
+		if err != nil {
+			return err
+		}
+
+		if isLess {
+			return ErrSeekBeforePosition
+		}
+	}
+
+	mi.seekPos = seekKey
+	return nil
+}
+
+// seekCursorForward moves |cur| forward to the first item whose key is >= |key|. Chunks whose keys are all less than
+// |key| are skipped by seeking the parent cursor rather than visiting their items. Returns false if there is no such
+// item, in which case |cur| is left past the end.
+func seekCursorForward(ctx context.Context, cur *sequenceCursor, key orderedKey) (bool, error) {
+	seq := cur.seq.(orderedSequence)
+	lastKey, err := seq.getKey(cur.length() - 1)
+
+	if err != nil {
+		return false, err
+	}
+
+	isLess, err := lastKey.Less(seq.format(), key)
+
+	if err != nil {
+		return false, err
+	}
+
+	if !isLess {
+		idx, err := seq.search(key)
+
+		if err != nil {
+			return false, err
+		}
+
+		if idx > cur.idx {
+			cur.idx = idx
+		}
+
+		return true, nil
+	}
+
+	if cur.parent != nil {
+		ok, err := seekCursorForward(ctx, cur.parent, key)
+
+		if err != nil {
+			return false, err
+		}
+
+		if ok {
+			err = cur.sync(ctx)
+
+			if err != nil {
+				return false, err
+			}
+
+			cur.idx, err = cur.seq.(orderedSequence).search(key)
+
+			if err != nil {
+				return false, err
+			}
+
+			return true, nil
+		}
+	}
+
+	cur.idx = cur.length()
+	return false, nil
+}
+
+// mergedMapIterator yields the entries of two MapIterators in key order.
+type mergedMapIterator struct {
+	nbf     *NomsBinFormat
+	a, b    MapIterator
+	aK, aV  Value
+	bK, bV  Value
+	started bool
+}
+
+// MergeMapIterators returns a MapIterator which yields the entries of |a| and |b| in key order. Both iterators must
+// produce their keys in ascending order. When both iterators contain the same key, the entry from |a| is returned
+// first, immediately followed by the entry from |b|.
+func MergeMapIterators(ctx context.Context, nbf *NomsBinFormat, a, b MapIterator) MapIterator {
+	return &mergedMapIterator{nbf: nbf, a: a, b: b}
+}
+
+// Next returns the next entry from either source iterator. If both sources are exhausted Next() returns nils.
+func (mi *mergedMapIterator) Next(ctx context.Context) (k, v Value, err error) {
+	if !mi.started {
+		mi.aK, mi.aV, err = mi.a.Next(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mi.bK, mi.bV, err = mi.b.Next(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mi.started = true
+	}
+
+	if mi.aK == nil && mi.bK == nil {
+		return nil, nil, nil
+	}
+
+	useB := mi.aK == nil
+	if mi.aK != nil && mi.bK != nil {
+		useB, err = mi.bK.Less(mi.nbf, mi.aK)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if useB {
+		k, v = mi.bK, mi.bV
+		mi.bK, mi.bV, err = mi.b.Next(ctx)
+	} else {
+		k, v = mi.aK, mi.aV
+		mi.aK, mi.aV, err = mi.a.Next(ctx)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return k, v, nil
+}
+
+// MapTupleIterator iterates over a Map whose keys and values are all Tuples.
+type MapTupleIterator struct {
+	itr MapIterator
+	nbf *NomsBinFormat
+}
+
+// TupleIterator returns an iterator over a Map whose keys and values are all Tuples.
+func (m Map) TupleIterator(ctx context.Context) (*MapTupleIterator, error) {
+	itr, err := m.Iterator(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MapTupleIterator{itr, m.Format()}, nil
+}
+
+// Next returns the next key and value of the Map, or io.EOF once all entries have been returned. An error is returned
+// if the key or value is not a Tuple.
+func (itr *MapTupleIterator) Next(ctx context.Context) (Tuple, Tuple, error) {
+	k, v, err := itr.itr.Next(ctx)
+
+	if err != nil {
+		return EmptyTuple(itr.nbf), EmptyTuple(itr.nbf), err
+	}
+
+	if k == nil {
+		return EmptyTuple(itr.nbf), EmptyTuple(itr.nbf), io.EOF
+	}
+
+	key, ok := k.(Tuple)
+
+	if !ok {
+		return EmptyTuple(itr.nbf), EmptyTuple(itr.nbf), fmt.Errorf("expected map key to be a Tuple but found %s", k.Kind())
+	}
+
+	val, ok := v.(Tuple)
+
+	if !ok {
+		return EmptyTuple(itr.nbf), EmptyTuple(itr.nbf), fmt.Errorf("expected map value to be a Tuple but found %s", v.Kind())
+	}
+
+	return key, val, nil
+}
+
+// MapKeyIterator iterates over the keys of a Map. The values of the Map are skipped rather than decoded, which makes
+// it cheaper than a MapIterator when only the keys are needed.
+type MapKeyIterator struct {
+	cur *sequenceCursor
+}
+
+// KeyIterator returns an iterator over the keys of the Map, in ascending order.
+func (m Map) KeyIterator(ctx context.Context) (*MapKeyIterator, error) {
+	cur, err := newCursorAtIndex(ctx, m.orderedSequence, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MapKeyIterator{cur}, nil
+}
+
+// Next returns the next key of the Map. If there are no more keys, Next returns nil.
+func (itr *MapKeyIterator) Next(ctx context.Context) (Value, error) {
+	if !itr.cur.valid() {
+		return nil, nil
+	}
+
+	ml, ok := itr.cur.seq.(mapLeafSequence)
+
+	if !ok {
+		return nil, fmt.Errorf("expected a map leaf sequence, got %T", itr.cur.seq)
+	}
+
+	dec := ml.decoderSkipToIndex(itr.cur.idx)
+	k, err := dec.readValue(ml.format())
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = itr.cur.advance(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
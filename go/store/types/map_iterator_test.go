@@ -23,6 +23,8 @@ package types
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -69,6 +71,12 @@ func TestMapIterator(t *testing.T) {
 	test(mustMIter(m.IteratorFrom(context.Background(), String("E"))), 4, "IteratorFrom(E)")
 	test(mustMIter(m.IteratorFrom(context.Background(), String("F"))), 5, "IteratorFrom(F)")
 	test(mustMIter(m.IteratorFrom(context.Background(), String("G"))), 5, "IteratorFrom(G)")
+	test(mustMIter(m.IteratorAfter(context.Background(), String("?"))), 0, "IteratorAfter(?)")
+	test(mustMIter(m.IteratorAfter(context.Background(), String("A"))), 1, "IteratorAfter(A)")
+	test(mustMIter(m.IteratorAfter(context.Background(), String("BB"))), 2, "IteratorAfter(BB)")
+	test(mustMIter(m.IteratorAfter(context.Background(), String("C"))), 3, "IteratorAfter(C)")
+	test(mustMIter(m.IteratorAfter(context.Background(), String("E"))), 5, "IteratorAfter(E)")
+	test(mustMIter(m.IteratorAfter(context.Background(), String("F"))), 5, "IteratorAfter(F)")
 }
 
 func TestReverseMapIterator(t *testing.T) {
@@ -127,3 +135,223 @@ func TestReverseMapIterator(t *testing.T) {
 	test(0, 0, "Iterate in reverse from the first key")
 	test(-1, 0, "Iterate in reverse from before the first day")
 }
+
+func TestMergeMapIterators(t *testing.T) {
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	mapOf := func(keys ...int) Map {
+		var kvs []Value
+		for _, k := range keys {
+			kvs = append(kvs, Int(k), String(fmt.Sprintf("%d", k)))
+		}
+		return mustMap(NewMap(ctx, vrw, kvs...))
+	}
+
+	tests := []struct {
+		name     string
+		a        []int
+		b        []int
+		expected []int
+	}{
+		{"both empty", nil, nil, nil},
+		{"a empty", nil, []int{1, 2, 3}, []int{1, 2, 3}},
+		{"b empty", []int{1, 2, 3}, nil, []int{1, 2, 3}},
+		{"disjoint", []int{1, 3, 5}, []int{2, 4, 6}, []int{1, 2, 3, 4, 5, 6}},
+		{"disjoint ranges", []int{1, 2, 3}, []int{7, 8, 9}, []int{1, 2, 3, 7, 8, 9}},
+		{"a exhausted early", []int{1, 2}, []int{3, 4, 5, 6}, []int{1, 2, 3, 4, 5, 6}},
+		{"overlapping", []int{1, 2, 3, 4}, []int{3, 4, 5}, []int{1, 2, 3, 3, 4, 4, 5}},
+		{"identical", []int{1, 2}, []int{1, 2}, []int{1, 1, 2, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := mapOf(test.a...), mapOf(test.b...)
+			itr := MergeMapIterators(ctx, vrw.Format(), mustMIter(a.Iterator(ctx)), mustMIter(b.Iterator(ctx)))
+
+			var actual []int
+			for {
+				k, v, err := itr.Next(ctx)
+				require.NoError(t, err)
+
+				if k == nil {
+					assert.Nil(t, v)
+					break
+				}
+
+				assert.Equal(t, String(fmt.Sprintf("%d", k.(Int))), v)
+				actual = append(actual, int(k.(Int)))
+			}
+
+			assert.Equal(t, test.expected, actual)
+
+			k, v, err := itr.Next(ctx)
+			assert.NoError(t, err)
+			assert.Nil(t, k)
+			assert.Nil(t, v)
+		})
+	}
+}
+
+func TestMapIteratorSeekTo(t *testing.T) {
+	ctx := context.Background()
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vrw := newTestValueStore()
+	me := mustMap(NewMap(ctx, vrw)).Edit()
+	for i := 0; i <= 1000; i += 2 {
+		me.Set(Int(i), Int(1000-i))
+	}
+
+	m, err := me.Map(ctx)
+	require.NoError(t, err)
+
+	iterators := map[string]func() MapIterator{
+		"Iterator":         func() MapIterator { return mustMIter(m.Iterator(ctx)) },
+		"BufferedIterator": func() MapIterator { return mustMIter(m.BufferedIterator(ctx)) },
+	}
+
+	for name, newItr := range iterators {
+		t.Run(name, func(t *testing.T) {
+			itr := newItr().(SeekableMapIterator)
+
+			expectNext := func(expected int) {
+				k, v, err := itr.Next(ctx)
+				require.NoError(t, err)
+				require.NotNil(t, k)
+				assert.Equal(t, Int(expected), k)
+				assert.Equal(t, Int(1000-expected), v)
+			}
+
+			require.NoError(t, itr.SeekTo(ctx, Int(0)))
+			expectNext(0)
+
+			require.NoError(t, itr.SeekTo(ctx, Int(500)))
+			expectNext(500)
+
+			require.NoError(t, itr.SeekTo(ctx, Int(501)))
+			expectNext(502)
+
+			// seeking to the current position is a no-op
+			require.NoError(t, itr.SeekTo(ctx, Int(504)))
+			expectNext(504)
+			expectNext(506)
+
+			assert.Equal(t, ErrSeekBeforePosition, itr.SeekTo(ctx, Int(100)))
+			expectNext(508)
+
+			require.NoError(t, itr.SeekTo(ctx, Int(999)))
+			expectNext(1000)
+
+			k, v, err := itr.Next(ctx)
+			assert.NoError(t, err)
+			assert.Nil(t, k)
+			assert.Nil(t, v)
+
+			itr = newItr().(SeekableMapIterator)
+			require.NoError(t, itr.SeekTo(ctx, Int(2000)))
+			k, v, err = itr.Next(ctx)
+			assert.NoError(t, err)
+			assert.Nil(t, k)
+			assert.Nil(t, v)
+
+			require.NoError(t, itr.SeekTo(ctx, Int(3000)))
+			k, _, err = itr.Next(ctx)
+			assert.NoError(t, err)
+			assert.Nil(t, k)
+		})
+	}
+}
+
+func TestMapTupleIterator(t *testing.T) {
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	me := mustMap(NewMap(ctx, vrw)).Edit()
+	for i := 0; i < 10; i++ {
+		me.Set(mustTuple(NewTuple(Format_7_18, Uint(0), Int(i))), mustTuple(NewTuple(Format_7_18, Uint(1), String(fmt.Sprint(i)))))
+	}
+
+	m, err := me.Map(ctx)
+	require.NoError(t, err)
+
+	itr, err := m.TupleIterator(ctx)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		k, v, err := itr.Next(ctx)
+		require.NoError(t, err)
+		assert.True(t, mustTuple(NewTuple(Format_7_18, Uint(0), Int(i))).Equals(k))
+		assert.True(t, mustTuple(NewTuple(Format_7_18, Uint(1), String(fmt.Sprint(i)))).Equals(v))
+	}
+
+	_, _, err = itr.Next(ctx)
+	assert.Equal(t, io.EOF, err)
+
+	m, err = NewMap(ctx, vrw, mustTuple(NewTuple(Format_7_18, Int(0))), Int(0))
+	require.NoError(t, err)
+	itr, err = m.TupleIterator(ctx)
+	require.NoError(t, err)
+	_, _, err = itr.Next(ctx)
+	assert.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+
+	m, err = NewMap(ctx, vrw, Int(0), mustTuple(NewTuple(Format_7_18, Int(0))))
+	require.NoError(t, err)
+	itr, err = m.TupleIterator(ctx)
+	require.NoError(t, err)
+	_, _, err = itr.Next(ctx)
+	assert.Error(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func TestMapKeyIterator(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	empty, err := NewMap(ctx, vrw)
+	require.NoError(t, err)
+	keyItr, err := empty.KeyIterator(ctx)
+	require.NoError(t, err)
+	k, err := keyItr.Next(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, k)
+
+	me := empty.Edit()
+	for i := 0; i < 1000; i++ {
+		v := String(fmt.Sprintf("%0100d", i))
+		me.Set(Int(i), v)
+		me.Set(mustTuple(NewTuple(Format_7_18, String("key"), Int(i))), v)
+	}
+	m, err := me.Map(ctx)
+	require.NoError(t, err)
+
+	itr, err := m.Iterator(ctx)
+	require.NoError(t, err)
+	keyItr, err = m.KeyIterator(ctx)
+	require.NoError(t, err)
+
+	count := 0
+	for {
+		expected, _, err := itr.Next(ctx)
+		require.NoError(t, err)
+		k, err := keyItr.Next(ctx)
+		require.NoError(t, err)
+
+		if expected == nil {
+			assert.Nil(t, k)
+			break
+		}
+
+		require.NotNil(t, k)
+		assert.True(t, expected.Equals(k))
+		count++
+	}
+
+	assert.Equal(t, int(m.Len()), count)
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
@@ -614,6 +615,150 @@ func TestNewMap(t *testing.T) {
 	assert.True(String("bar2").Equals(foo2Str))
 }
 
+func TestNewMapFromSortedTuples(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	pairs := make([]struct{ K, V Value }, 1000)
+	for i := range pairs {
+		pairs[i].K = mustTuple(NewTuple(Format_7_18, Int(i/10), Int(i%10)))
+		pairs[i].V = mustTuple(NewTuple(Format_7_18, String(fmt.Sprint(i))))
+	}
+
+	m, err := NewMapFromSortedTuples(ctx, vrw, pairs)
+	require.NoError(t, err)
+
+	me := mustMap(NewMap(ctx, vrw)).Edit()
+	for _, pair := range pairs {
+		me.Set(pair.K, pair.V)
+	}
+
+	expected, err := me.Map(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Len(), m.Len())
+	assert.True(t, expected.Equals(m))
+
+	empty, err := NewMapFromSortedTuples(ctx, vrw, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), empty.Len())
+
+	pairs[500], pairs[501] = pairs[501], pairs[500]
+	_, err = NewMapFromSortedTuples(ctx, vrw, pairs)
+	assert.Equal(t, ErrKeysNotOrdered, err)
+
+	pairs[500] = pairs[501]
+	_, err = NewMapFromSortedTuples(ctx, vrw, pairs)
+	assert.Equal(t, ErrKeysNotOrdered, err)
+}
+
+func makeSortedTuplePairs(n int) []struct{ K, V Value } {
+	pairs := make([]struct{ K, V Value }, n)
+	for i := range pairs {
+		pairs[i].K = mustTuple(NewTuple(Format_7_18, Uint(0), Int(i)))
+		pairs[i].V = mustTuple(NewTuple(Format_7_18, Uint(1), String(fmt.Sprint(i))))
+	}
+
+	return pairs
+}
+
+func BenchmarkNewMapFromSortedTuples(b *testing.B) {
+	ctx := context.Background()
+	vrw := newTestValueStore()
+	pairs := makeSortedTuplePairs(100000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := NewMapFromSortedTuples(ctx, vrw, pairs)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkMapEditorFromSortedTuples(b *testing.B) {
+	ctx := context.Background()
+	vrw := newTestValueStore()
+	pairs := makeSortedTuplePairs(100000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		me := mustMap(NewMap(ctx, vrw)).Edit()
+		for _, pair := range pairs {
+			me.Set(pair.K, pair.V)
+		}
+
+		_, err := me.Map(ctx)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkMapEditorBatchSize(b *testing.B) {
+	ctx := context.Background()
+	vrw := newTestValueStore()
+	pairs := makeSortedTuplePairs(1000000)
+
+	// apply the edits in descending key order, so that each batch must be sorted
+	for _, batchSize := range []int{0, 10000, 100000} {
+		b.Run(fmt.Sprintf("batch size %d", batchSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				me := mustMap(NewMap(ctx, vrw)).Edit()
+				me.SetBatchSize(batchSize)
+				for j := len(pairs) - 1; j >= 0; j-- {
+					me.Set(pairs[j].K, pairs[j].V)
+				}
+
+				_, err := me.Map(ctx)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// makeNearlyEqualMaps returns two maps of n entries which differ only in the value of their middle entry.
+func makeNearlyEqualMaps(b *testing.B, n int) (Map, Map) {
+	ctx := context.Background()
+	vrw := newTestValueStore()
+	pairs := makeSortedTuplePairs(n)
+
+	m, err := NewMapFromSortedTuples(ctx, vrw, pairs)
+	require.NoError(b, err)
+
+	mid := pairs[n/2].K
+	other, err := m.Edit().Set(mid, mustTuple(NewTuple(Format_7_18, Uint(1), String("changed")))).Map(ctx)
+	require.NoError(b, err)
+
+	return m, other
+}
+
+func BenchmarkMapEqualsRange(b *testing.B) {
+	ctx := context.Background()
+	m, other := makeNearlyEqualMaps(b, 1000000)
+	start := mustTuple(NewTuple(Format_7_18, Uint(0), Int(0)))
+	end := mustTuple(NewTuple(Format_7_18, Uint(0), Int(1000000)))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		eq, err := m.EqualsRange(ctx, other, start, end)
+		require.NoError(b, err)
+		require.False(b, eq)
+	}
+}
+
+func BenchmarkMapDiffKeys(b *testing.B) {
+	ctx := context.Background()
+	m, other := makeNearlyEqualMaps(b, 1000000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		added, removed, changed, err := m.DiffKeys(ctx, other)
+		require.NoError(b, err)
+		require.Empty(b, added)
+		require.Empty(b, removed)
+		require.Len(b, changed, 1)
+	}
+}
+
 func TestMapUniqueKeysString(t *testing.T) {
 	vrw := newTestValueStore()
 
@@ -945,6 +1090,138 @@ func TestMapLast2(t *testing.T) {
 	doTest(getTestRefToValueOrderMap, 2)
 }
 
+func TestMapFirstKeyLastKey(t *testing.T) {
+	assert := assert.New(t)
+
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	vrw := newTestValueStore()
+
+	m, err := NewMap(context.Background(), vrw)
+	assert.NoError(err)
+	k, err := m.FirstKey(context.Background())
+	assert.NoError(err)
+	assert.Nil(k)
+	k, err = m.LastKey(context.Background())
+	assert.NoError(err)
+	assert.Nil(k)
+
+	doTest := func(toTestMap toTestMapFunc, scale int) {
+		vrw := newTestValueStore()
+		tm := toTestMap(scale, vrw)
+		m := tm.toMap(vrw)
+		err := SortWithErroringLess(tm.entries)
+		assert.NoError(err)
+
+		first, err := m.FirstKey(context.Background())
+		assert.NoError(err)
+		assert.True(tm.entries.entries[0].key.Equals(first))
+
+		last, err := m.LastKey(context.Background())
+		assert.NoError(err)
+		assert.True(tm.entries.entries[len(tm.entries.entries)-1].key.Equals(last))
+	}
+
+	doTest(getTestNativeOrderMap, 16)
+	doTest(getTestRefValueOrderMap, 2)
+}
+
+func TestMapSample(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	empty := mustMap(NewMap(ctx, vrw))
+	keys, err := empty.Sample(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	me := empty.Edit()
+	for i := 0; i < 1000; i++ {
+		me.Set(Int(i*2), Int(i))
+	}
+	m, err := me.Map(ctx)
+	require.NoError(t, err)
+
+	first, err := m.FirstKey(ctx)
+	require.NoError(t, err)
+	last, err := m.LastKey(ctx)
+	require.NoError(t, err)
+
+	for _, n := range []int{2, 10, 333, 999} {
+		keys, err := m.Sample(ctx, n)
+		require.NoError(t, err)
+		require.Len(t, keys, n)
+		assert.True(t, first.Equals(keys[0]))
+		assert.True(t, last.Equals(keys[len(keys)-1]))
+
+		for i := 1; i < len(keys); i++ {
+			assert.True(t, keys[i-1].(Int) < keys[i].(Int))
+		}
+	}
+
+	keys, err = m.Sample(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.True(t, first.Equals(keys[0]))
+
+	keys, err = m.Sample(ctx, 2000)
+	require.NoError(t, err)
+	require.Len(t, keys, 1000)
+	for i, k := range keys {
+		assert.True(t, Int(i*2).Equals(k))
+	}
+
+	keys, err = m.Sample(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestMapKeysValues(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	empty := mustMap(NewMap(ctx, vrw))
+	keys, err := empty.Keys(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+	vals, err := empty.Values(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, vals)
+
+	kvs := make([]Value, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Int(i*2), String(fmt.Sprint(i)))
+	}
+	m := mustMap(NewMap(ctx, vrw, kvs...))
+
+	for _, limit := range []int{-1, 0, 1, 10, 999, 1000, 2000} {
+		expectedLen := limit
+		if limit <= 0 || limit > 1000 {
+			expectedLen = 1000
+		}
+
+		keys, err := m.Keys(ctx, limit)
+		require.NoError(t, err)
+		require.Len(t, keys, expectedLen)
+
+		vals, err := m.Values(ctx, limit)
+		require.NoError(t, err)
+		require.Len(t, vals, expectedLen)
+
+		for i := 0; i < expectedLen; i++ {
+			assert.True(t, Int(i*2).Equals(keys[i]))
+			assert.True(t, String(fmt.Sprint(i)).Equals(vals[i]))
+		}
+	}
+}
+
 func TestMapSetGet(t *testing.T) {
 	assert := assert.New(t)
 
@@ -2118,3 +2395,589 @@ func TestMapWithNil(t *testing.T) {
 		NewSet(context.Background(), vrw, String("a"), String("b"), Float(42), nil)
 	})
 }
+
+func TestMapEditorRemoveRange(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	const size = 1000
+	kvs := make([]Value, 0, 2*size)
+	for i := 0; i < size; i++ {
+		kvs = append(kvs, Int(i), String(fmt.Sprint(i)))
+	}
+	m, err := NewMap(ctx, vrw, kvs...)
+	require.NoError(t, err)
+
+	// expectedMap builds the map with only the keys for which keep returns true.
+	expectedMap := func(keep func(i int) bool, extra ...Value) Map {
+		var kvs []Value
+		for i := 0; i < size; i++ {
+			if keep(i) {
+				kvs = append(kvs, Int(i), String(fmt.Sprint(i)))
+			}
+		}
+		me := mustMap(NewMap(ctx, vrw, kvs...)).Edit()
+		me.SetM(toValuable(extra)...)
+		return mustMap(me.Map(ctx))
+	}
+
+	tests := []struct {
+		name     string
+		edit     func(me *MapEditor)
+		expected Map
+	}{
+		{
+			"single range",
+			func(me *MapEditor) {
+				me.RemoveRange(Int(100), Int(900))
+			},
+			expectedMap(func(i int) bool { return i < 100 || i >= 900 }),
+		},
+		{
+			"range past the end",
+			func(me *MapEditor) {
+				me.RemoveRange(Int(990), Int(2000))
+			},
+			expectedMap(func(i int) bool { return i < 990 }),
+		},
+		{
+			"range outside of the map",
+			func(me *MapEditor) {
+				me.RemoveRange(Int(-10), Int(0))
+				me.RemoveRange(Int(size), Int(size+10))
+			},
+			m,
+		},
+		{
+			"empty range",
+			func(me *MapEditor) {
+				me.RemoveRange(Int(500), Int(500))
+				me.RemoveRange(Int(600), Int(400))
+			},
+			m,
+		},
+		{
+			"overlapping ranges",
+			func(me *MapEditor) {
+				me.RemoveRange(Int(0), Int(300))
+				me.RemoveRange(Int(200), Int(400))
+			},
+			expectedMap(func(i int) bool { return i >= 400 }),
+		},
+		{
+			"range and point edits",
+			func(me *MapEditor) {
+				me.Remove(Int(50))
+				me.Set(Int(150), String("new 150"))
+				me.RemoveRange(Int(100), Int(200))
+				me.Set(Int(size+1), String("new"))
+				me.Remove(Int(199))
+				me.Set(Int(300), String("new 300"))
+			},
+			expectedMap(
+				func(i int) bool { return i != 50 && (i < 100 || i >= 200) },
+				Int(size+1), String("new"),
+				Int(300), String("new 300"),
+			),
+		},
+		{
+			"set then remove range",
+			func(me *MapEditor) {
+				me.Set(Int(150), String("new 150"))
+				me.Set(Int(size+1), String("new"))
+				me.RemoveRange(Int(100), Int(200))
+			},
+			expectedMap(
+				func(i int) bool { return i < 100 || i >= 200 },
+				Int(size+1), String("new"),
+			),
+		},
+		{
+			"remove range then set",
+			func(me *MapEditor) {
+				me.RemoveRange(Int(100), Int(200))
+				me.Set(Int(150), String("new 150"))
+				me.Set(Int(size+1), String("new"))
+			},
+			expectedMap(
+				func(i int) bool { return i < 100 || i >= 200 },
+				Int(150), String("new 150"),
+				Int(size+1), String("new"),
+			),
+		},
+		{
+			"interleaved ranges and point edits",
+			func(me *MapEditor) {
+				me.RemoveRange(Int(0), Int(100))
+				me.Set(Int(50), String("new 50"))
+				me.Set(Int(150), String("new 150"))
+				me.RemoveRange(Int(140), Int(160))
+				me.Remove(Int(50))
+				me.Set(Int(10), String("new 10"))
+			},
+			expectedMap(
+				func(i int) bool { return i >= 100 && (i < 140 || i >= 160) },
+				Int(10), String("new 10"),
+			),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			me := m.Edit()
+			test.edit(me)
+			actual, err := me.Map(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected.Len(), actual.Len())
+			assert.True(t, test.expected.Equals(actual))
+		})
+	}
+}
+
+func TestMapEditorBatchSize(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	const size = 1000
+	kvs := make([]Value, 0, 2*size)
+	for i := 0; i < size; i++ {
+		kvs = append(kvs, Int(i), String(fmt.Sprint(i)))
+	}
+	m := mustMap(NewMap(ctx, vrw, kvs...))
+
+	// edit sets every even key, then removes every fourth key, so that keys are edited by more than one batch
+	edit := func(me *MapEditor) {
+		for i := 0; i < 2*size; i += 2 {
+			me.Set(Int(i), String("new"))
+		}
+		for i := 0; i < 2*size; i += 4 {
+			me.Remove(Int(i))
+		}
+	}
+
+	me := m.Edit()
+	edit(me)
+	expected := mustMap(me.Map(ctx))
+
+	for _, batchSize := range []int{1, 7, 100, 2 * size, 10 * size} {
+		t.Run(fmt.Sprintf("batch size %d", batchSize), func(t *testing.T) {
+			me := m.Edit()
+			me.SetBatchSize(batchSize)
+			edit(me)
+			assert.Equal(t, int64(3*size/2), me.NumEdits())
+
+			actual, err := me.Map(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, expected.Len(), actual.Len())
+			assert.True(t, expected.Equals(actual))
+		})
+	}
+}
+
+func TestMapEditorBatchSizeWithRanges(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	const size = 1000
+	kvs := make([]Value, 0, 2*size)
+	for i := 0; i < size; i++ {
+		kvs = append(kvs, Int(i), String(fmt.Sprint(i)))
+	}
+	m := mustMap(NewMap(ctx, vrw, kvs...))
+
+	// edit interleaves range removals with sets and removes of keys inside and outside of the ranges, so that whether a
+	// key is present depends on the order in which the edits are applied
+	edit := func(me *MapEditor) {
+		for i := 0; i < size; i += 100 {
+			me.Set(Int(i+10), String("before"))
+			me.Remove(Int(i + 90))
+			me.RemoveRange(Int(i), Int(i+50))
+			me.Set(Int(i+20), String("after"))
+			me.Set(Int(i+60), String("after"))
+			me.Remove(Int(i + 70))
+		}
+	}
+
+	expected := mustMap(NewMap(ctx, vrw))
+	expectedEd := expected.Edit()
+	for i := 0; i < size; i++ {
+		switch j := i % 100; {
+		case j == 20 || j == 60:
+			expectedEd.Set(Int(i), String("after"))
+		case j < 50 || j == 70 || j == 90:
+			// removed
+		default:
+			expectedEd.Set(Int(i), String(fmt.Sprint(i)))
+		}
+	}
+	expected = mustMap(expectedEd.Map(ctx))
+
+	for _, batchSize := range []int{0, 1, 2, 3, 7, 100, 10 * size} {
+		t.Run(fmt.Sprintf("batch size %d", batchSize), func(t *testing.T) {
+			me := m.Edit()
+			me.SetBatchSize(batchSize)
+			edit(me)
+			assert.Equal(t, int64(6*size/100), me.NumEdits())
+
+			actual, err := me.Map(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, expected.Len(), actual.Len())
+			assert.True(t, expected.Equals(actual))
+		})
+	}
+}
+
+func TestMapEqualsRange(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	kvs := make([]Value, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Int(i*2), Int(i))
+	}
+	m := mustMap(NewMap(ctx, vrw, kvs...))
+
+	// other modifies the value at key 500, removes key 1000 and adds key 1501
+	other := mustMap(m.Edit().Set(Int(500), Int(-1)).Remove(Int(1000)).Set(Int(1501), Int(0)).Map(ctx))
+
+	tests := []struct {
+		start    Value
+		end      Value
+		expected bool
+	}{
+		{Int(0), Int(500), true},
+		{Int(0), Int(501), false},
+		{Int(500), Int(501), false},
+		{Int(501), Int(1000), true},
+		{Int(501), Int(1001), false},
+		{Int(1000), Int(1000), true},
+		{Int(1001), Int(1501), true},
+		{Int(1001), Int(1502), false},
+		{Int(1502), Int(10000), true},
+		{Int(-100), Int(0), true},
+		{Int(3000), Int(4000), true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("[%v, %v)", test.start, test.end), func(t *testing.T) {
+			eq, err := m.EqualsRange(ctx, other, test.start, test.end)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, eq)
+
+			eq, err = other.EqualsRange(ctx, m, test.start, test.end)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, eq)
+
+			eq, err = m.EqualsRange(ctx, m, test.start, test.end)
+			require.NoError(t, err)
+			assert.True(t, eq)
+		})
+	}
+
+	empty := mustMap(NewMap(ctx, vrw))
+	eq, err := m.EqualsRange(ctx, empty, Int(1), Int(2))
+	require.NoError(t, err)
+	assert.True(t, eq)
+
+	eq, err = m.EqualsRange(ctx, empty, Int(0), Int(2))
+	require.NoError(t, err)
+	assert.False(t, eq)
+}
+
+func TestMapUpdateFrom(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	kvs := make([]Value, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Int(i*2), Int(i))
+	}
+	m := mustMap(NewMap(ctx, vrw, kvs...))
+
+	// overlay overrides keys 0 and 1000, adds keys 1 and 5001, and removes keys 2 and 1998
+	overlay := mustMap(NewMap(ctx, vrw,
+		Int(0), Int(-1),
+		Int(1), Int(-2),
+		Int(2), NullValue,
+		Int(1000), Int(-3),
+		Int(1998), NullValue,
+		Int(5001), Int(-4),
+		Int(7777), NullValue,
+	))
+
+	updated, err := m.UpdateFrom(ctx, overlay)
+	require.NoError(t, err)
+
+	expected := mustMap(m.Edit().
+		Set(Int(0), Int(-1)).
+		Set(Int(1), Int(-2)).
+		Remove(Int(2)).
+		Set(Int(1000), Int(-3)).
+		Remove(Int(1998)).
+		Set(Int(5001), Int(-4)).
+		Map(ctx))
+
+	assert.True(t, expected.Equals(updated))
+	assert.Equal(t, uint64(1000), updated.Len())
+
+	// updating from an empty map makes no changes
+	updated, err = m.UpdateFrom(ctx, mustMap(NewMap(ctx, vrw)))
+	require.NoError(t, err)
+	assert.True(t, m.Equals(updated))
+
+	// updating an empty map adds the entries of the overlay which are not tombstones
+	updated, err = mustMap(NewMap(ctx, vrw)).UpdateFrom(ctx, overlay)
+	require.NoError(t, err)
+	expected = mustMap(NewMap(ctx, vrw, Int(0), Int(-1), Int(1), Int(-2), Int(1000), Int(-3), Int(5001), Int(-4)))
+	assert.True(t, expected.Equals(updated))
+}
+
+func TestMapDiffKeys(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	kvs := make([]Value, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Int(i*2), Int(i))
+	}
+	last := mustMap(NewMap(ctx, vrw, kvs...))
+
+	m := mustMap(last.Edit().
+		Set(Int(1), Int(0)).
+		Set(Int(2001), Int(0)).
+		Remove(Int(0)).
+		Remove(Int(1000)).
+		Set(Int(500), Int(-1)).
+		Set(Int(1998), Int(-1)).
+		Set(Int(600), Int(300)).
+		Map(ctx))
+
+	added, removed, changed, err := m.DiffKeys(ctx, last)
+	require.NoError(t, err)
+	assert.Equal(t, []Value{Int(1), Int(2001)}, added)
+	assert.Equal(t, []Value{Int(0), Int(1000)}, removed)
+	assert.Equal(t, []Value{Int(500), Int(1998)}, changed)
+
+	// reversing the maps swaps added and removed keys
+	added, removed, changed, err = last.DiffKeys(ctx, m)
+	require.NoError(t, err)
+	assert.Equal(t, []Value{Int(0), Int(1000)}, added)
+	assert.Equal(t, []Value{Int(1), Int(2001)}, removed)
+	assert.Equal(t, []Value{Int(500), Int(1998)}, changed)
+
+	added, removed, changed, err = m.DiffKeys(ctx, m)
+	require.NoError(t, err)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+	assert.Empty(t, changed)
+
+	empty := mustMap(NewMap(ctx, vrw))
+	added, removed, changed, err = empty.DiffKeys(ctx, last)
+	require.NoError(t, err)
+	assert.Empty(t, added)
+	assert.Len(t, removed, 1000)
+	assert.Empty(t, changed)
+}
+
+func TestMapStreamDiff(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	kvs := make([]Value, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Int(i*2), Int(i))
+	}
+	last := mustMap(NewMap(ctx, vrw, kvs...))
+
+	m := mustMap(last.Edit().
+		Set(Int(1), Int(0)).
+		Remove(Int(1000)).
+		Set(Int(1998), Int(-1)).
+		Set(Int(2001), Int(0)).
+		Map(ctx))
+
+	streamDiff := func(ctx context.Context, m, last Map) ([]ValueChanged, error) {
+		changes := make(chan ValueChanged)
+		errChan := make(chan error, 1)
+		go func() {
+			defer close(changes)
+			errChan <- m.StreamDiff(ctx, last, changes)
+		}()
+
+		var actual []ValueChanged
+		for change := range changes {
+			actual = append(actual, change)
+		}
+		return actual, <-errChan
+	}
+
+	actual, err := streamDiff(ctx, m, last)
+	require.NoError(t, err)
+	assert.Equal(t, []ValueChanged{
+		{DiffChangeAdded, Int(1), nil, Int(0)},
+		{DiffChangeRemoved, Int(1000), Int(500), nil},
+		{DiffChangeModified, Int(1998), Int(999), Int(-1)},
+		{DiffChangeAdded, Int(2001), nil, Int(0)},
+	}, actual)
+
+	actual, err = streamDiff(ctx, m, m)
+	require.NoError(t, err)
+	assert.Empty(t, actual)
+
+	// canceling the context stops the diff
+	cancelCtx, cancel := context.WithCancel(ctx)
+	changes := make(chan ValueChanged)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- m.StreamDiff(cancelCtx, last, changes)
+	}()
+
+	<-changes
+	cancel()
+	assert.Equal(t, context.Canceled, <-errChan)
+}
+
+func TestMapContainsRange(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	kvs := make([]Value, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Int(i*2), Int(i))
+	}
+	m := mustMap(NewMap(ctx, vrw, kvs...))
+
+	tests := []struct {
+		start    Value
+		end      Value
+		expected bool
+	}{
+		{Int(0), Int(1), true},
+		{Int(1), Int(2), false},
+		{Int(1), Int(3), true},
+		{Int(501), Int(502), false},
+		{Int(501), Int(503), true},
+		{Int(1998), Int(1999), true},
+		{Int(1999), Int(10000), false},
+		{Int(-100), Int(0), false},
+		{Int(-100), Int(10000), true},
+		{Int(10), Int(10), false},
+		{Int(20), Int(10), false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("[%v, %v)", test.start, test.end), func(t *testing.T) {
+			ok, err := m.ContainsRange(ctx, test.start, test.end)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, ok)
+		})
+	}
+
+	empty := mustMap(NewMap(ctx, vrw))
+	ok, err := empty.ContainsRange(ctx, Int(-100), Int(10000))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestMapSplitPoints(t *testing.T) {
+	smallTestChunks()
+	defer normalProductionChunks()
+
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	kvs := make([]Value, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		kvs = append(kvs, Int(i*2), Int(i))
+	}
+	m := mustMap(NewMap(ctx, vrw, kvs...))
+
+	for _, n := range []int{0, 1, 2, 3, 7, 16, 100, 1000, 5000} {
+		splits, err := m.SplitPoints(ctx, n)
+		require.NoError(t, err)
+
+		if n <= 1 {
+			assert.Empty(t, splits)
+			continue
+		}
+
+		assert.NotEmpty(t, splits)
+		assert.True(t, len(splits) < n, "n: %d, splits: %d", n, len(splits))
+
+		for i := 1; i < len(splits); i++ {
+			isLess, err := splits[i-1].Less(Format_7_18, splits[i])
+			require.NoError(t, err)
+			assert.True(t, isLess, "n: %d", n)
+		}
+
+		// the segments between the split keys must cover every key exactly once and none may be empty
+		var keys []Value
+		starts := append([]Value{nil}, splits...)
+		for i, start := range starts {
+			var itr MapIterator
+			if start == nil {
+				itr, err = m.Iterator(ctx)
+			} else {
+				itr, err = m.IteratorFrom(ctx, start)
+			}
+			require.NoError(t, err)
+
+			var segment []Value
+			for {
+				k, _, err := itr.Next(ctx)
+				require.NoError(t, err)
+
+				if k == nil {
+					break
+				}
+
+				if i < len(splits) {
+					inSegment, err := k.Less(Format_7_18, splits[i])
+					require.NoError(t, err)
+
+					if !inSegment {
+						break
+					}
+				}
+
+				segment = append(segment, k)
+			}
+
+			assert.NotEmpty(t, segment, "n: %d, segment: %d", n, i)
+			keys = append(keys, segment...)
+		}
+
+		require.Equal(t, 1000, len(keys), "n: %d", n)
+		for i, k := range keys {
+			assert.True(t, Int(i*2).Equals(k), "n: %d", n)
+		}
+	}
+
+	splits, err := mustMap(NewMap(ctx, vrw)).SplitPoints(ctx, 4)
+	require.NoError(t, err)
+	assert.Empty(t, splits)
+}
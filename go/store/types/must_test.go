@@ -59,6 +59,11 @@ func mustMap(m Map, err error) Map {
 	return m
 }
 
+func mustTuple(t Tuple, err error) Tuple {
+	d.PanicIfError(err)
+	return t
+}
+
 func mustSet(s Set, err error) Set {
 	d.PanicIfError(err)
 	return s
@@ -24,11 +24,32 @@ package types
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/liquidata-inc/dolt/go/store/d"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
+// ErrInvalidEncodedValue is returned when bytes passed to AppendEncoded are not a single encoded value.
+var ErrInvalidEncodedValue = errors.New("bytes are not a single encoded value")
+
+// ErrInvalidSerializedTuple is returned by DeserializeTuple when its input was not produced by Tuple.Serialize.
+var ErrInvalidSerializedTuple = errors.New("bytes are not a serialized tuple")
+
+// ErrSerializedTupleFormat is returned by DeserializeTuple when a tuple was serialized in a different NomsBinFormat.
+var ErrSerializedTupleFormat = errors.New("serialized tuple has a different format")
+
+// ErrOddNumberOfFields is returned by ForEachPair when a tuple does not have an even number of fields.
+var ErrOddNumberOfFields = errors.New("tuple has an odd number of fields")
+
+// ErrUnexpectedFieldKind is wrapped by the errors of the typed field accessors, such as GetString, when a field does not
+// have the expected kind.
+var ErrUnexpectedFieldKind = errors.New("tuple field has an unexpected kind")
+
 func EmptyTuple(nbf *NomsBinFormat) Tuple {
 	t, err := NewTuple(nbf)
 	d.PanicIfError(err)
@@ -71,6 +92,68 @@ func (itr *TupleIterator) Pos() uint64 {
 	return itr.pos
 }
 
+// ChainedTupleIterator iterates over the fields of several TupleIterators in sequence, as if the fields of each came
+// after the fields of the one before it. Positions continue across the boundaries between iterators, so the first
+// field of an iterator is positioned after the last field of the previous one.
+type ChainedTupleIterator struct {
+	iters  []*TupleIterator
+	offset uint64
+	count  uint64
+}
+
+// ChainTupleIterators returns a ChainedTupleIterator over the fields of iters, in order.
+func ChainTupleIterators(iters ...*TupleIterator) *ChainedTupleIterator {
+	var count uint64
+	for _, itr := range iters {
+		count += itr.Len()
+	}
+
+	return &ChainedTupleIterator{iters, 0, count}
+}
+
+func (itr *ChainedTupleIterator) Next() (uint64, Value, error) {
+	itr.skipExhausted()
+
+	if len(itr.iters) == 0 {
+		return itr.count, nil, nil
+	}
+
+	pos, val, err := itr.iters[0].Next()
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return itr.offset + pos, val, nil
+}
+
+func (itr *ChainedTupleIterator) HasMore() bool {
+	itr.skipExhausted()
+	return len(itr.iters) > 0
+}
+
+func (itr *ChainedTupleIterator) Len() uint64 {
+	return itr.count
+}
+
+func (itr *ChainedTupleIterator) Pos() uint64 {
+	itr.skipExhausted()
+
+	if len(itr.iters) == 0 {
+		return itr.count
+	}
+
+	return itr.offset + itr.iters[0].Pos()
+}
+
+// skipExhausted moves past the iterators which have no more fields.
+func (itr *ChainedTupleIterator) skipExhausted() {
+	for len(itr.iters) > 0 && !itr.iters[0].HasMore() {
+		itr.offset += itr.iters[0].Len()
+		itr.iters = itr.iters[1:]
+	}
+}
+
 type Tuple struct {
 	valueImpl
 }
@@ -114,28 +197,83 @@ func walkTuple(nbf *NomsBinFormat, r *refWalker, cb RefCallback) error {
 }
 
 func NewTuple(nbf *NomsBinFormat, values ...Value) (Tuple, error) {
-	var vrw ValueReadWriter
 	w := newBinaryNomsWriter()
-	err := TupleKind.writeTo(&w, nbf)
+	err := WriteTuple(&w, nbf, values...)
 
 	if err != nil {
 		return EmptyTuple(nbf), err
 	}
 
-	numVals := len(values)
-	w.writeCount(uint64(numVals))
-	for i := 0; i < numVals; i++ {
-		if vrw == nil {
-			vrw = values[i].(valueReadWriter).valueReadWriter()
+	var vrw ValueReadWriter
+	for _, v := range values {
+		vrw = v.(valueReadWriter).valueReadWriter()
+
+		if vrw != nil {
+			break
+		}
+	}
+
+	return Tuple{valueImpl{vrw, nbf, w.data(), nil}}, nil
+}
+
+// WriteTuple writes the encoding of a tuple of values to w, in the format nbf, after whatever w already holds. The
+// bytes written are identical to those of the Tuple created by NewTuple, so a tuple can be encoded as part of a larger
+// structure without first being created on its own and then copied.
+func WriteTuple(w *binaryNomsWriter, nbf *NomsBinFormat, values ...Value) error {
+	err := TupleKind.writeTo(w, nbf)
+
+	if err != nil {
+		return err
+	}
+
+	w.writeCount(uint64(len(values)))
+	for _, v := range values {
+		err := v.writeTo(w, nbf)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NewFixedTuple creates a tuple from values after checking that there are exactly arity of them. It is meant for
+// tuples which must always have the same number of fields, such as the rows of a schema, where a tuple of the wrong
+// size is a bug. Use NewTuple to create tuples of any size.
+func NewFixedTuple(nbf *NomsBinFormat, arity int, values ...Value) (Tuple, error) {
+	if len(values) != arity {
+		return EmptyTuple(nbf), fmt.Errorf("expected %d tuple fields but got %d", arity, len(values))
+	}
+
+	return NewTuple(nbf, values...)
+}
+
+// NewTupleOfTypes creates a tuple from values after validating that each value is of the type in the same position of
+// expected. A nil entry in expected allows a value of any type in that position. An error identifying the first field
+// that does not match is returned if validation fails.
+func NewTupleOfTypes(nbf *NomsBinFormat, expected []*Type, values ...Value) (Tuple, error) {
+	if len(expected) != len(values) {
+		return EmptyTuple(nbf), fmt.Errorf("expected %d tuple fields but got %d", len(expected), len(values))
+	}
+
+	for i, v := range values {
+		if expected[i] == nil {
+			continue
 		}
-		err := values[i].writeTo(&w, nbf)
+
+		isSubtype, err := IsValueSubtypeOf(nbf, v, expected[i])
 
 		if err != nil {
 			return EmptyTuple(nbf), err
 		}
+
+		if !isSubtype {
+			return EmptyTuple(nbf), fmt.Errorf("tuple field %d of kind %s does not match expected kind %s", i, v.Kind(), expected[i].TargetKind())
+		}
 	}
 
-	return Tuple{valueImpl{vrw, nbf, w.data(), nil}}, nil
+	return NewTuple(nbf, values...)
 }
 
 func (t Tuple) Empty() bool {
@@ -146,14 +284,141 @@ func (t Tuple) Format() *NomsBinFormat {
 	return t.format()
 }
 
+// WithFormat returns t encoded in the format nbf. Each field is decoded in the format of t and encoded in nbf, and
+// tuples nested within t are re-encoded as well. If t is already encoded in nbf it is returned as is, without a copy.
+func (t Tuple) WithFormat(nbf *NomsBinFormat) (Tuple, error) {
+	if t.format() == nbf {
+		return t, nil
+	}
+
+	vals := make([]Value, 0, t.Len())
+	err := t.IterFields(func(_ uint64, v Value) (bool, error) {
+		if nested, ok := v.(Tuple); ok {
+			var err error
+			v, err = nested.WithFormat(nbf)
+
+			if err != nil {
+				return true, err
+			}
+		}
+
+		vals = append(vals, v)
+		return false, nil
+	})
+
+	if err != nil {
+		return EmptyTuple(nbf), err
+	}
+
+	converted, err := NewTuple(nbf, vals...)
+
+	if err != nil {
+		return EmptyTuple(nbf), err
+	}
+
+	converted.vrw = t.vrw
+	return converted, nil
+}
+
+// Clone returns a copy of the tuple backed by a newly allocated buffer. Tuples read from a chunk share the chunk's
+// buffer, so retaining one keeps the entire chunk in memory. Clone a tuple before holding on to it beyond the lifetime
+// of the data it was read from, e.g. when keeping a few tuples from a large page of decoded values.
+func (t Tuple) Clone() Tuple {
+	buff := make([]byte, len(t.buff))
+	copy(buff, t.buff)
+
+	var offsets []uint32
+	if t.offsets != nil {
+		offsets = make([]uint32, len(t.offsets))
+		copy(offsets, t.offsets)
+	}
+
+	return Tuple{valueImpl{t.vrw, t.nbf, buff, offsets}}
+}
+
+const (
+	serializedTupleMagic   byte = 'T'
+	serializedTupleVersion byte = 1
+)
+
+// Serialize returns a copy of the tuple's encoded bytes for storage outside of noms, prefixed by a header recording the
+// tuple's NomsBinFormat. Use DeserializeTuple to read the tuple back. The header is a magic byte, the version of the
+// serialization, the length of the format's version string and the version string itself.
+func (t Tuple) Serialize() []byte {
+	version := t.format().VersionString()
+	b := make([]byte, 0, 3+len(version)+len(t.buff))
+	b = append(b, serializedTupleMagic, serializedTupleVersion, byte(len(version)))
+	b = append(b, version...)
+	return append(b, t.buff...)
+}
+
+// DeserializeTuple reads a tuple written by Tuple.Serialize. ErrInvalidSerializedTuple is returned if b does not have a
+// valid header followed by exactly one encoded tuple, and ErrSerializedTupleFormat is returned if the tuple was
+// serialized in a format other than nbf. The returned tuple does not share memory with b.
+func DeserializeTuple(nbf *NomsBinFormat, vrw ValueReadWriter, b []byte) (Tuple, error) {
+	if len(b) < 3 || b[0] != serializedTupleMagic || b[1] != serializedTupleVersion {
+		return EmptyTuple(nbf), ErrInvalidSerializedTuple
+	}
+
+	versionEnd := 3 + int(b[2])
+
+	if len(b) <= versionEnd {
+		return EmptyTuple(nbf), ErrInvalidSerializedTuple
+	}
+
+	if string(b[3:versionEnd]) != nbf.VersionString() {
+		return EmptyTuple(nbf), ErrSerializedTupleFormat
+	}
+
+	raw := b[versionEnd:]
+	dec := newValueDecoder(raw, vrw)
+
+	if dec.peekKind() != TupleKind {
+		return EmptyTuple(nbf), ErrInvalidSerializedTuple
+	}
+
+	err := skipTuple(nbf, &dec)
+
+	if err != nil {
+		return EmptyTuple(nbf), err
+	}
+
+	if int(dec.offset) != len(raw) {
+		return EmptyTuple(nbf), ErrInvalidSerializedTuple
+	}
+
+	buff := make([]byte, len(raw))
+	copy(buff, raw)
+
+	return Tuple{valueImpl{vrw, nbf, buff, nil}}, nil
+}
+
 // Value interface
 func (t Tuple) Value(ctx context.Context) (Value, error) {
 	return t, nil
 }
 
+// WalkValues calls cb for each field of the tuple, like WalkValuesErr. The walk stops early if ctx is canceled, but
+// unlike WalkValuesErr the context's error is not returned, so only errors from decoding fields or from cb are.
 func (t Tuple) WalkValues(ctx context.Context, cb ValueCallback) error {
+	err := t.WalkValuesErr(ctx, cb)
+
+	if err != nil && err == ctx.Err() {
+		return nil
+	}
+
+	return err
+}
+
+// WalkValuesErr calls cb for each field of the tuple, stopping early if cb returns an error. ctx is checked before
+// each field is decoded, and the walk stops with ctx.Err() once the context is done.
+func (t Tuple) WalkValuesErr(ctx context.Context, cb ValueCallback) error {
 	dec, count := t.decoderSkipToFields()
 	for i := uint64(0); i < count; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		v, err := dec.readValue(t.format())
 
 		if err != nil {
@@ -235,17 +500,30 @@ func (t Tuple) Iterator() (*TupleIterator, error) {
 }
 
 func (t Tuple) IteratorAt(pos uint64) (*TupleIterator, error) {
+	itr := &TupleIterator{}
+	err := t.initIterator(itr, pos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return itr, nil
+}
+
+// initIterator positions itr at field pos of t.
+func (t Tuple) initIterator(itr *TupleIterator, pos uint64) error {
 	dec, count := t.decoderSkipToFields()
 
 	for i := uint64(0); i < pos; i++ {
 		err := dec.skipValue(t.format())
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return &TupleIterator{dec, count, pos, t.format()}, nil
+	*itr = TupleIterator{dec, count, pos, t.format()}
+	return nil
 }
 
 // IterFields iterates over the fields, calling cb for every field in the tuple until cb returns false
@@ -277,176 +555,1071 @@ func (t Tuple) IterFields(cb func(index uint64, value Value) (stop bool, err err
 	return nil
 }
 
-// Get returns the value of a field in the tuple. If the tuple does not a have a field at the index then this panics
-func (t Tuple) Get(n uint64) (Value, error) {
+// IterFieldSpans calls cb with the encoded bytes of every field of the tuple, including their kind prefixes, until cb
+// returns true. The fields are skipped over without being decoded. Each slice aliases the tuple's buffer rather than
+// being a copy, so it must not be modified, and a caller that reuses the buffer must not retain it.
+func (t Tuple) IterFieldSpans(cb func(index uint64, raw []byte) (stop bool)) error {
 	dec, count := t.decoderSkipToFields()
 
-	if n >= count {
-		d.Chk.Fail(fmt.Sprintf(`tuple index "%d" out of range`, n))
-	}
-
-	for i := uint64(0); i < n; i++ {
+	for i := uint64(0); i < count; i++ {
+		start := dec.offset
 		err := dec.skipValue(t.format())
 
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		if cb(i, dec.buff[start:dec.offset:dec.offset]) {
+			break
 		}
 	}
 
-	return dec.readValue(t.format())
+	return nil
 }
 
-// Set returns a new tuple where the field at index n is set to value. Attempting to use Set on an index that is outside
-// of the bounds will cause a panic.  Use Append to add additional values, not Set.
-func (t Tuple) Set(n uint64, v Value) (Tuple, error) {
-	prolog, head, tail, count, found, err := t.splitFieldsAt(n)
+// ForEachPair iterates over the fields of a tuple of the form [k0, v0, k1, v1, ...], calling cb with each key and value
+// pair. Iteration stops early if cb returns true or an error. ErrOddNumberOfFields is returned, without calling cb, if
+// the tuple has an odd number of fields.
+func (t Tuple) ForEachPair(cb func(k, v Value) (stop bool, err error)) error {
+	if t.Len()%2 != 0 {
+		return ErrOddNumberOfFields
+	}
+
+	itr, err := t.Iterator()
 
 	if err != nil {
-		return EmptyTuple(t.nbf), err
+		return err
 	}
 
-	if !found {
-		d.Panic("Cannot set tuple value at index %d as it is outside the range [0,%d]", n, count-1)
+	for itr.HasMore() {
+		_, k, err := itr.Next()
+
+		if err != nil {
+			return err
+		}
+
+		_, v, err := itr.Next()
+
+		if err != nil {
+			return err
+		}
+
+		stop, err := cb(k, v)
+
+		if err != nil {
+			return err
+		}
+
+		if stop {
+			break
+		}
 	}
 
-	w := binaryNomsWriter{make([]byte, len(t.buff)), 0}
-	w.writeRaw(prolog)
+	return nil
+}
 
-	w.writeCount(count)
-	w.writeRaw(head)
-	err = v.writeTo(&w, t.format())
+// AsMap decodes the tuple once and returns a map from field index to field value containing exactly Len() entries.
+// The returned map is a snapshot of the tuple's fields. It is not a live view and modifying it does not modify the tuple.
+func (t Tuple) AsMap() (map[uint64]Value, error) {
+	dec, count := t.decoderSkipToFields()
+	fields := make(map[uint64]Value, count)
 
-	if err != nil {
-		return EmptyTuple(t.nbf), err
+	for i := uint64(0); i < count; i++ {
+		v, err := dec.readValue(t.format())
+
+		if err != nil {
+			return nil, err
+		}
+
+		fields[i] = v
 	}
-	w.writeRaw(tail)
 
-	return Tuple{valueImpl{t.vrw, t.format(), w.data(), nil}}, nil
+	return fields, nil
 }
 
-func (t Tuple) Append(v Value) (Tuple, error) {
-	dec := t.decoder()
-	dec.skipKind()
-	prolog := dec.buff[:dec.offset]
-	count := dec.readCount()
-	fieldsOffset := dec.offset
+// CountFields returns the number of fields for which pred returns true. A nil pred counts every field.
+func (t Tuple) CountFields(pred func(index uint64, v Value) bool) (uint64, error) {
+	if pred == nil {
+		return t.Len(), nil
+	}
 
-	w := binaryNomsWriter{make([]byte, len(t.buff)), 0}
-	w.writeRaw(prolog)
-	w.writeCount(count + 1)
-	w.writeRaw(dec.buff[fieldsOffset:])
-	err := v.writeTo(&w, t.format())
+	itr, err := t.Iterator()
 
 	if err != nil {
-		return EmptyTuple(t.nbf), err
+		return 0, err
 	}
 
-	return Tuple{valueImpl{t.vrw, t.format(), w.data(), nil}}, nil
+	var n uint64
+	for itr.HasMore() {
+		i, v, err := itr.Next()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if pred(i, v) {
+			n++
+		}
+	}
+
+	return n, nil
 }
 
-// splitFieldsAt splits the buffer into two parts. The fields coming before the field we are looking for
-// and the fields coming after it.
-func (t Tuple) splitFieldsAt(n uint64) (prolog, head, tail []byte, count uint64, found bool, err error) {
-	dec := t.decoder()
-	dec.skipKind()
-	prolog = dec.buff[:dec.offset]
-	count = dec.readCount()
+// Get returns the value of a field in the tuple. If the tuple does not a have a field at the index then this panics
+func (t Tuple) Get(n uint64) (Value, error) {
+	dec, count := t.decoderSkipToFields()
 
 	if n >= count {
-		return nil, nil, nil, count, false, nil
+		d.Chk.Fail(fmt.Sprintf(`tuple index "%d" out of range`, n))
 	}
 
-	found = true
-	fieldsOffset := dec.offset
-
 	for i := uint64(0); i < n; i++ {
 		err := dec.skipValue(t.format())
 
 		if err != nil {
-			return nil, nil, nil, 0, false, err
+			return nil, err
 		}
 	}
 
-	head = dec.buff[fieldsOffset:dec.offset]
-
-	if n != count-1 {
-		err := dec.skipValue(t.format())
+	return dec.readValue(t.format())
+}
 
-		if err != nil {
-			return nil, nil, nil, 0, false, err
-		}
+// GetString returns the value of field n, which must be a String. Like Get, it panics if n is out of range. An error
+// wrapping ErrUnexpectedFieldKind is returned if the field is not a String.
+func (t Tuple) GetString(n uint64) (string, error) {
+	v, err := t.getKind(n, StringKind)
 
-		tail = dec.buff[dec.offset:len(dec.buff)]
+	if err != nil {
+		return "", err
 	}
 
-	return
+	return string(v.(String)), nil
 }
 
-/*func (t Tuple) Equals(otherVal Value) bool {
-	if otherTuple, ok := otherVal.(Tuple); ok {
-		itr := t.Iterator()
-		otherItr := otherTuple.Iterator()
+// GetFloat returns the value of field n, which must be a Float. Like Get, it panics if n is out of range. An error
+// wrapping ErrUnexpectedFieldKind is returned if the field is not a Float.
+func (t Tuple) GetFloat(n uint64) (float64, error) {
+	v, err := t.getKind(n, FloatKind)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(v.(Float)), nil
+}
+
+// GetBool returns the value of field n, which must be a Bool. Like Get, it panics if n is out of range. An error
+// wrapping ErrUnexpectedFieldKind is returned if the field is not a Bool.
+func (t Tuple) GetBool(n uint64) (bool, error) {
+	v, err := t.getKind(n, BoolKind)
+
+	if err != nil {
+		return false, err
+	}
+
+	return bool(v.(Bool)), nil
+}
+
+// GetInt returns the value of field n, which must be an Int. Like Get, it panics if n is out of range. An error
+// wrapping ErrUnexpectedFieldKind is returned if the field is not an Int, including if it is a Uint.
+func (t Tuple) GetInt(n uint64) (int64, error) {
+	v, err := t.getKind(n, IntKind)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(v.(Int)), nil
+}
+
+// getKind returns the value of field n after checking that it has the kind expected.
+func (t Tuple) getKind(n uint64, expected NomsKind) (Value, error) {
+	v, err := t.Get(n)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Kind() != expected {
+		return nil, fmt.Errorf("%w: field %d is a %s, not a %s", ErrUnexpectedFieldKind, n, v.Kind(), expected)
+	}
+
+	return v, nil
+}
+
+// Last returns the value of the last field of t, and false if t has no fields. The preceding fields are skipped in a
+// single pass using the field count from the tuple's header, and only the last field is decoded.
+func (t Tuple) Last() (Value, bool, error) {
+	dec, count := t.decoderSkipToFields()
+
+	if count == 0 {
+		return nil, false, nil
+	}
+
+	for i := uint64(0); i < count-1; i++ {
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	v, err := dec.readValue(t.format())
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return v, true, nil
+}
+
+// GetWithType returns the value of the field at index n along with its type. The field is decoded once: the type of a
+// primitive field is known from its kind, and the type of a struct field is read from its encoding, so only the types of
+// collections and tuples are computed from their decoded values. As with TypeOf, the types of non-primitive fields are
+// simplified. Attempting to read a field outside of the bounds will cause a panic.
+func (t Tuple) GetWithType(n uint64) (Value, *Type, error) {
+	dec, count := t.decoderSkipToFields()
+
+	if n >= count {
+		d.Chk.Fail(fmt.Sprintf(`tuple index "%d" out of range`, n))
+	}
+
+	for i := uint64(0); i < n; i++ {
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	k := dec.peekKind()
+
+	var typ *Type
+	if primType, ok := PrimitiveTypeMap[k]; ok {
+		typ = primType
+	} else if k == StructKind {
+		typeDec := dec
+		structType, err := readStructTypeOfValue(t.format(), &typeDec)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		typ = structType
+	}
+
+	v, err := dec.readValue(t.format())
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if typ == nil {
+		typ, err = v.typeOf()
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, ok := PrimitiveTypeMap[k]; !ok {
+		// match TypeOf, which simplifies the types of composite values
+		typ, err = simplifyType(typ, false)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return v, typ, nil
+}
+
+// EqualsAt returns whether field n of t is equal to field m of other. Only the fields being compared are decoded. Like
+// Get, it panics if either index is out of range.
+func (t Tuple) EqualsAt(n uint64, other Tuple, m uint64) (bool, error) {
+	v, err := t.Get(n)
+
+	if err != nil {
+		return false, err
+	}
+
+	otherV, err := other.Get(m)
+
+	if err != nil {
+		return false, err
+	}
+
+	return v.Equals(otherV), nil
+}
+
+// FieldBytes returns the encoded bytes of the field at index n, including its kind prefix. The returned slice aliases
+// the tuple's buffer and must not be modified. Attempting to read a field outside of the bounds will cause a panic.
+func (t Tuple) FieldBytes(n uint64) ([]byte, error) {
+	dec, count := t.decoderSkipToFields()
+
+	if n >= count {
+		d.Chk.Fail(fmt.Sprintf(`tuple index "%d" out of range`, n))
+	}
+
+	for i := uint64(0); i < n; i++ {
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start := dec.offset
+	err := dec.skipValue(t.format())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dec.buff[start:dec.offset:dec.offset], nil
+}
+
+// ByteOffsetOf returns the offset within the tuple's encoded bytes at which the field at index n begins. An n equal to
+// Len returns the offset of the end of the fields. Any larger n will cause a panic.
+func (t Tuple) ByteOffsetOf(n uint64) (int, error) {
+	dec, count := t.decoderSkipToFields()
+
+	if n > count {
+		d.Chk.Fail(fmt.Sprintf(`tuple index "%d" out of range`, n))
+	}
+
+	for i := uint64(0); i < n; i++ {
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return int(dec.offset), nil
+}
+
+// Set returns a new tuple where the field at index n is set to value. Attempting to use Set on an index that is outside
+// of the bounds will cause a panic.  Use Append to add additional values, not Set.
+func (t Tuple) Set(n uint64, v Value) (Tuple, error) {
+	prolog, head, tail, count, found, err := t.splitFieldsAt(n)
+
+	if err != nil {
+		return EmptyTuple(t.nbf), err
+	}
+
+	if !found {
+		d.Panic("Cannot set tuple value at index %d as it is outside the range [0,%d]", n, count-1)
+	}
+
+	w := binaryNomsWriter{make([]byte, len(t.buff)), 0}
+	w.writeRaw(prolog)
+
+	w.writeCount(count)
+	w.writeRaw(head)
+	err = v.writeTo(&w, t.format())
+
+	if err != nil {
+		return EmptyTuple(t.nbf), err
+	}
+	w.writeRaw(tail)
+
+	return Tuple{valueImpl{t.vrw, t.format(), w.data(), nil}}, nil
+}
+
+func (t Tuple) Append(v Value) (Tuple, error) {
+	dec := t.decoder()
+	dec.skipKind()
+	prolog := dec.buff[:dec.offset]
+	count := dec.readCount()
+	fieldsOffset := dec.offset
+
+	w := binaryNomsWriter{make([]byte, len(t.buff)), 0}
+	w.writeRaw(prolog)
+	w.writeCount(count + 1)
+	w.writeRaw(dec.buff[fieldsOffset:])
+	err := v.writeTo(&w, t.format())
+
+	if err != nil {
+		return EmptyTuple(t.nbf), err
+	}
+
+	return Tuple{valueImpl{t.vrw, t.format(), w.data(), nil}}, nil
+}
+
+// AppendEncoded appends a field using the bytes of a value that has already been encoded in the tuple's format, which
+// avoids decoding and re-encoding values when copying fields between tuples. raw must contain exactly one complete
+// value encoded in the tuple's format. This is checked by skipping over the value, and ErrInvalidEncodedValue is
+// returned if raw is empty or has bytes remaining after the value.
+func (t Tuple) AppendEncoded(raw []byte) (Tuple, error) {
+	if len(raw) == 0 {
+		return EmptyTuple(t.nbf), ErrInvalidEncodedValue
+	}
+
+	rawDec := newValueDecoder(raw, t.vrw)
+	err := rawDec.skipValue(t.format())
+
+	if err != nil {
+		return EmptyTuple(t.nbf), err
+	}
+
+	if int(rawDec.offset) != len(raw) {
+		return EmptyTuple(t.nbf), ErrInvalidEncodedValue
+	}
+
+	dec := t.decoder()
+	dec.skipKind()
+	prolog := dec.buff[:dec.offset]
+	count := dec.readCount()
+	fieldsOffset := dec.offset
+
+	w := binaryNomsWriter{make([]byte, len(t.buff)+len(raw)), 0}
+	w.writeRaw(prolog)
+	w.writeCount(count + 1)
+	w.writeRaw(dec.buff[fieldsOffset:])
+	w.writeRaw(raw)
+
+	return Tuple{valueImpl{t.vrw, t.format(), w.data(), nil}}, nil
+}
+
+// FillDefaults pads a tuple with fewer than arity fields up to arity fields, for reading a tuple written under an older,
+// narrower schema. defaults holds a value for every field of the wider schema, and the field at each missing index i is
+// set to defaults[i]. Tuples that already have at least arity fields are returned unchanged. An error is returned if
+// defaults does not have a value for each missing field.
+func (t Tuple) FillDefaults(arity uint64, defaults []Value) (Tuple, error) {
+	dec := t.decoder()
+	dec.skipKind()
+	prolog := dec.buff[:dec.offset]
+	count := dec.readCount()
+	fieldsOffset := dec.offset
+
+	if count >= arity {
+		return t, nil
+	}
+
+	if uint64(len(defaults)) < arity {
+		return EmptyTuple(t.nbf), fmt.Errorf("expected %d tuple field defaults but got %d", arity, len(defaults))
+	}
+
+	w := binaryNomsWriter{make([]byte, len(t.buff)), 0}
+	w.writeRaw(prolog)
+	w.writeCount(arity)
+	w.writeRaw(dec.buff[fieldsOffset:])
+
+	for _, v := range defaults[count:arity] {
+		err := v.writeTo(&w, t.format())
+
+		if err != nil {
+			return EmptyTuple(t.nbf), err
+		}
+	}
+
+	return Tuple{valueImpl{t.vrw, t.format(), w.data(), nil}}, nil
+}
+
+// AppendIfChanged appends v to the tuple only if the tuple's last field is not equal to v. An empty tuple always has v
+// appended. Returns the resulting tuple, which is t itself when no append happened, and whether v was appended.
+func (t Tuple) AppendIfChanged(v Value) (Tuple, bool, error) {
+	dec, count := t.decoderSkipToFields()
+
+	if count > 0 {
+		for i := uint64(0); i < count-1; i++ {
+			err := dec.skipValue(t.format())
+
+			if err != nil {
+				return EmptyTuple(t.nbf), false, err
+			}
+		}
+
+		last, err := dec.readValue(t.format())
+
+		if err != nil {
+			return EmptyTuple(t.nbf), false, err
+		}
+
+		if last.Equals(v) {
+			return t, false, nil
+		}
+	}
+
+	appended, err := t.Append(v)
+
+	if err != nil {
+		return EmptyTuple(t.nbf), false, err
+	}
+
+	return appended, true, nil
+}
+
+// PrefixHash returns the hash of a tuple made up of the first k fields of t, without decoding the fields or
+// building the tuple. Attempting to use a k larger than the number of fields will cause a panic.
+func (t Tuple) PrefixHash(nbf *NomsBinFormat, k uint64) (hash.Hash, error) {
+	dec := t.decoder()
+	dec.skipKind()
+	prolog := dec.buff[:dec.offset]
+	count := dec.readCount()
+
+	if k > count {
+		d.Panic("Cannot hash a prefix of %d fields of a tuple with %d fields", k, count)
+	}
+
+	fieldsOffset := dec.offset
+	for i := uint64(0); i < k; i++ {
+		err := dec.skipValue(nbf)
+
+		if err != nil {
+			return hash.Hash{}, err
+		}
+	}
+
+	return hash.Of(encodeTupleFields(prolog, k, dec.buff[fieldsOffset:dec.offset])), nil
+}
+
+// SplitAt returns two tuples, the first containing the fields [0,n) of t and the second containing the fields
+// [n,Len()). The encoded bytes of the fields are copied without being decoded. Attempting to split at an index larger
+// than the number of fields will cause a panic.
+func (t Tuple) SplitAt(n uint64) (head, tail Tuple, err error) {
+	dec := t.decoder()
+	dec.skipKind()
+	prolog := dec.buff[:dec.offset]
+	count := dec.readCount()
+
+	if n > count {
+		d.Panic("Cannot split tuple at index %d as it is outside the range [0,%d]", n, count)
+	}
+
+	fieldsOffset := dec.offset
+	for i := uint64(0); i < n; i++ {
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return EmptyTuple(t.nbf), EmptyTuple(t.nbf), err
+		}
+	}
+
+	splitOffset := dec.offset
+	head = Tuple{valueImpl{t.vrw, t.format(), encodeTupleFields(prolog, n, dec.buff[fieldsOffset:splitOffset]), nil}}
+	tail = Tuple{valueImpl{t.vrw, t.format(), encodeTupleFields(prolog, count-n, dec.buff[splitOffset:]), nil}}
+
+	return head, tail, nil
+}
+
+// Project returns a tuple of the fields of t at indices, in the order they are given. Fields may be reordered, and a
+// field may be selected more than once. The encoded bytes of the selected fields are copied without being decoded, and
+// fields after the largest index are not read. Every index is checked before any field is read, and an index outside
+// of the range [0,Len()) will cause a panic.
+func (t Tuple) Project(indices ...uint64) (Tuple, error) {
+	dec := t.decoder()
+	dec.skipKind()
+	prolog := dec.buff[:dec.offset]
+	count := dec.readCount()
+
+	var maxIdx uint64
+	for _, idx := range indices {
+		if idx >= count {
+			d.Panic("Cannot project field %d of a tuple with %d fields", idx, count)
+		}
+
+		if idx > maxIdx {
+			maxIdx = idx
+		}
+	}
+
+	var spans [][]byte
+	if len(indices) > 0 {
+		spans = make([][]byte, maxIdx+1)
+		for i := range spans {
+			start := dec.offset
+			err := dec.skipValue(t.format())
+
+			if err != nil {
+				return EmptyTuple(t.nbf), err
+			}
+
+			spans[i] = dec.buff[start:dec.offset]
+		}
+	}
+
+	var fields []byte
+	for _, idx := range indices {
+		fields = append(fields, spans[idx]...)
+	}
+
+	return Tuple{valueImpl{t.vrw, t.format(), encodeTupleFields(prolog, uint64(len(indices)), fields), nil}}, nil
+}
+
+// ReplaceRange returns a tuple with the fields [start,end) of t replaced by values. The number of values does not need
+// to match the size of the range, so when start == end the values are inserted before field start, and when no values
+// are given the range is removed. The encoded bytes of the fields outside of the range are copied without being
+// decoded. Attempting to replace a range which is inverted or extends past the end of t will cause a panic.
+func (t Tuple) ReplaceRange(start, end uint64, values ...Value) (Tuple, error) {
+	dec := t.decoder()
+	dec.skipKind()
+	prolog := dec.buff[:dec.offset]
+	count := dec.readCount()
+
+	if start > end || end > count {
+		d.Panic("Cannot replace tuple fields [%d,%d) as they are outside the range [0,%d]", start, end, count)
+	}
+
+	fieldsOffset := dec.offset
+	startOffset := fieldsOffset
+	for i := uint64(0); i < end; i++ {
+		if i == start {
+			startOffset = dec.offset
+		}
+
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return EmptyTuple(t.nbf), err
+		}
+	}
+
+	if start == end {
+		startOffset = dec.offset
+	}
+
+	w := binaryNomsWriter{make([]byte, len(t.buff)), 0}
+	w.writeRaw(prolog)
+	w.writeCount(count - (end - start) + uint64(len(values)))
+	w.writeRaw(dec.buff[fieldsOffset:startOffset])
+
+	for _, v := range values {
+		err := v.writeTo(&w, t.format())
+
+		if err != nil {
+			return EmptyTuple(t.nbf), err
+		}
+	}
+
+	w.writeRaw(dec.buff[dec.offset:])
+
+	return Tuple{valueImpl{t.vrw, t.format(), w.data(), nil}}, nil
+}
+
+// encodeTupleFields returns the encoding of a tuple with the given prolog, field count and encoded fields.
+func encodeTupleFields(prolog []byte, count uint64, fields []byte) []byte {
+	w := binaryNomsWriter{make([]byte, len(prolog)+binary.MaxVarintLen64+len(fields)), 0}
+	w.writeRaw(prolog)
+	w.writeCount(count)
+	w.writeRaw(fields)
+
+	return w.data()
+}
+
+// splitFieldsAt splits the buffer into two parts. The fields coming before the field we are looking for
+// and the fields coming after it.
+func (t Tuple) splitFieldsAt(n uint64) (prolog, head, tail []byte, count uint64, found bool, err error) {
+	dec := t.decoder()
+	dec.skipKind()
+	prolog = dec.buff[:dec.offset]
+	count = dec.readCount()
+
+	if n >= count {
+		return nil, nil, nil, count, false, nil
+	}
+
+	found = true
+	fieldsOffset := dec.offset
+
+	for i := uint64(0); i < n; i++ {
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return nil, nil, nil, 0, false, err
+		}
+	}
+
+	head = dec.buff[fieldsOffset:dec.offset]
+
+	if n != count-1 {
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return nil, nil, nil, 0, false, err
+		}
+
+		tail = dec.buff[dec.offset:len(dec.buff)]
+	}
+
+	return
+}
+
+/*func (t Tuple) Equals(otherVal Value) bool {
+	if otherTuple, ok := otherVal.(Tuple); ok {
+		itr := t.Iterator()
+		otherItr := otherTuple.Iterator()
 
 		if itr.Len() != otherItr.Len() {
 			return false
 		}
 
-		for itr.HasMore() {
-			_, val := itr.Next()
-			_, otherVal := otherItr.Next()
+		for itr.HasMore() {
+			_, val := itr.Next()
+			_, otherVal := otherItr.Next()
+
+			if !val.Equals(otherVal) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}*/
+
+func (t Tuple) Less(nbf *NomsBinFormat, other LesserValuable) (bool, error) {
+	if otherTuple, ok := other.(Tuple); ok {
+		c, err := t.Compare(nbf, otherTuple)
+
+		if err != nil {
+			return false, err
+		}
+
+		return c < 0, nil
+	}
+
+	return TupleKind < other.Kind(), nil
+}
+
+// Compare returns -1 if t is less than other, 1 if other is less than t, and 0 if they are equal, in a single walk
+// over their fields. Tuples are ordered by their first unequal field, and a tuple which is a prefix of another is
+// ordered before it. Compare returns 0 exactly when t.Equals(other), so callers which need both the ordering and the
+// equality of two tuples need not walk their fields twice.
+func (t Tuple) Compare(nbf *NomsBinFormat, other Tuple) (int, error) {
+	itr, err := t.Iterator()
+
+	if err != nil {
+		return 0, err
+	}
+
+	otherItr, err := other.Iterator()
+
+	if err != nil {
+		return 0, err
+	}
+
+	for itr.HasMore() {
+		if !otherItr.HasMore() {
+			// equal up til the end of other. other is shorter, therefore it is less
+			return 1, nil
+		}
+
+		_, currVal, err := itr.Next()
+
+		if err != nil {
+			return 0, err
+		}
+
+		_, currOthVal, err := otherItr.Next()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if currVal.Equals(currOthVal) {
+			continue
+		}
+
+		isLess, err := currVal.Less(nbf, currOthVal)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if isLess {
+			return -1, nil
+		}
+
+		return 1, nil
+	}
+
+	if itr.Len() < otherItr.Len() {
+		return -1, nil
+	}
+
+	return 0, nil
+}
+
+// LessThanEncoded orders t and the tuple encoded in raw like Less, without creating a Tuple from raw. raw must be the
+// encoding of a tuple in the format nbf. Leading fields with identical encodings are skipped over without being
+// decoded, and only the first pair of fields with different encodings is decoded and compared. ErrInvalidEncodedValue
+// is returned if raw is not the encoding of a tuple.
+func (t Tuple) LessThanEncoded(nbf *NomsBinFormat, raw []byte) (bool, error) {
+	if len(raw) == 0 {
+		return false, ErrInvalidEncodedValue
+	}
+
+	rawDec := newValueDecoder(raw, t.vrw)
+
+	if rawDec.peekKind() != TupleKind {
+		return false, ErrInvalidEncodedValue
+	}
+
+	rawDec.skipKind()
+	rawCount := rawDec.readCount()
+	dec, count := t.decoderSkipToFields()
+
+	for i := uint64(0); i < count; i++ {
+		if i == rawCount {
+			// equal up til the end of raw. raw is shorter, therefore it is less
+			return false, nil
+		}
+
+		start, rawStart := dec.offset, rawDec.offset
+		err := dec.skipValue(nbf)
+
+		if err != nil {
+			return false, err
+		}
+
+		err = rawDec.skipValue(nbf)
 
-			if !val.Equals(otherVal) {
-				return false
-			}
+		if err != nil {
+			return false, err
 		}
 
-		return true
+		if bytes.Equal(dec.buff[start:dec.offset], rawDec.buff[rawStart:rawDec.offset]) {
+			continue
+		}
+
+		dec.offset, rawDec.offset = start, rawStart
+		currVal, err := dec.readValue(nbf)
+
+		if err != nil {
+			return false, err
+		}
+
+		rawVal, err := rawDec.readValue(nbf)
+
+		if err != nil {
+			return false, err
+		}
+
+		if currVal.Equals(rawVal) {
+			continue
+		}
+
+		return currVal.Less(nbf, rawVal)
 	}
 
-	return false
-}*/
+	return count < rawCount, nil
+}
 
-func (t Tuple) Less(nbf *NomsBinFormat, other LesserValuable) (bool, error) {
-	if otherTuple, ok := other.(Tuple); ok {
-		itr, err := t.Iterator()
+// FieldComparator compares two tuple fields at the same position, returning a negative number if a is ordered before b,
+// a positive number if a is ordered after b, and 0 if they are equal.
+type FieldComparator func(nbf *NomsBinFormat, a, b Value) (int, error)
+
+// DefaultFieldComparator orders fields the way Less does.
+func DefaultFieldComparator(nbf *NomsBinFormat, a, b Value) (int, error) {
+	if a.Equals(b) {
+		return 0, nil
+	}
+
+	isLess, err := a.Less(nbf, b)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if isLess {
+		return -1, nil
+	}
+
+	return 1, nil
+}
+
+// DescendingFieldComparator reverses the order of cmp, or of DefaultFieldComparator if cmp is nil.
+func DescendingFieldComparator(cmp FieldComparator) FieldComparator {
+	if cmp == nil {
+		cmp = DefaultFieldComparator
+	}
+
+	return func(nbf *NomsBinFormat, a, b Value) (int, error) {
+		return cmp(nbf, b, a)
+	}
+}
+
+// LessWith orders t and other like Less, except that field i is compared with cmps[i]. Fields past the end of cmps,
+// and fields whose comparator is nil, are compared with DefaultFieldComparator. This allows composite keys to mix
+// ascending and descending fields, or to order some fields by a collation.
+func (t Tuple) LessWith(nbf *NomsBinFormat, other Tuple, cmps []FieldComparator) (bool, error) {
+	itr, err := t.Iterator()
+
+	if err != nil {
+		return false, err
+	}
+
+	otherItr, err := other.Iterator()
+
+	if err != nil {
+		return false, err
+	}
+
+	for itr.HasMore() {
+		if !otherItr.HasMore() {
+			return false, nil
+		}
+
+		i, currVal, err := itr.Next()
 
 		if err != nil {
 			return false, err
 		}
 
-		otherItr, err := otherTuple.Iterator()
+		_, currOthVal, err := otherItr.Next()
 
 		if err != nil {
 			return false, err
 		}
 
-		for itr.HasMore() {
-			if !otherItr.HasMore() {
-				// equal up til the end of other. other is shorter, therefore it is less
-				return false, nil
-			}
+		cmp := DefaultFieldComparator
+		if i < uint64(len(cmps)) && cmps[i] != nil {
+			cmp = cmps[i]
+		}
 
-			_, currVal, err := itr.Next()
+		c, err := cmp(nbf, currVal, currOthVal)
 
-			if err != nil {
-				return false, err
-			}
+		if err != nil {
+			return false, err
+		}
+
+		if c != 0 {
+			return c < 0, nil
+		}
+	}
+
+	return itr.Len() < otherItr.Len(), nil
+}
+
+// LessWithNulls orders t and other like Less, except that NULL fields are ordered before all other values when
+// nullsFirst is true, and after all other values when it is false. A NULL field of a tuple is represented by
+// NullValue, which Less orders according to its kind. Only the fields of t and other are treated specially; NULLs
+// within nested values are ordered as they are by Less.
+func (t Tuple) LessWithNulls(nbf *NomsBinFormat, other Tuple, nullsFirst bool) (bool, error) {
+	c, err := TupleComparer{NullsFirst: nullsFirst}.compare(nbf, t, other)
+
+	if err != nil {
+		return false, err
+	}
+
+	return c < 0, nil
+}
+
+// EqualsNumericTolerant returns whether t and other have the same fields, comparing Int, Uint and Float fields by their
+// numeric value rather than by their encoding, so Int(1), Uint(1) and Float(1) are all equal. All other fields must be
+// strictly equal.
+func (t Tuple) EqualsNumericTolerant(other Tuple) (bool, error) {
+	if t.Len() != other.Len() {
+		return false, nil
+	}
+
+	itr, err := t.Iterator()
+
+	if err != nil {
+		return false, err
+	}
+
+	otherItr, err := other.Iterator()
+
+	if err != nil {
+		return false, err
+	}
+
+	for itr.HasMore() {
+		_, val, err := itr.Next()
+
+		if err != nil {
+			return false, err
+		}
+
+		_, otherVal, err := otherItr.Next()
+
+		if err != nil {
+			return false, err
+		}
+
+		if val.Equals(otherVal) {
+			continue
+		}
+
+		if !numericValuesEqual(val, otherVal) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// numericValuesEqual returns whether a and b are Int, Uint or Float values with the same numeric value.
+func numericValuesEqual(a, b Value) bool {
+	switch a := a.(type) {
+	case Int:
+		switch b := b.(type) {
+		case Int:
+			return a == b
+		case Uint:
+			return a >= 0 && uint64(a) == uint64(b)
+		case Float:
+			return Float(a) == b && float64(b) >= math.MinInt64 && float64(b) < math.MaxInt64 && int64(b) == int64(a)
+		}
+	case Uint:
+		switch b.(type) {
+		case Int, Float:
+			return numericValuesEqual(b, a)
+		case Uint:
+			return a == b
+		}
+	case Float:
+		switch b := b.(type) {
+		case Int:
+			return numericValuesEqual(b, a)
+		case Uint:
+			return Float(b) == a && float64(a) >= 0 && float64(a) < math.MaxUint64 && uint64(a) == uint64(b)
+		case Float:
+			return a == b
+		}
+	}
+
+	return false
+}
+
+// FieldsAreOrdered returns whether each field of the tuple is greater than or equal to the field before it, according
+// to Value.Less. It is a diagnostic for checking the invariants of tuples which are expected to hold sorted values, such
+// as composite keys built from sorted sub-keys. Tuples are not required to be ordered in order to be stored.
+func (t Tuple) FieldsAreOrdered(nbf *NomsBinFormat) (bool, error) {
+	itr, err := t.Iterator()
+
+	if err != nil {
+		return false, err
+	}
+
+	var prev Value
+	for itr.HasMore() {
+		_, curr, err := itr.Next()
+
+		if err != nil {
+			return false, err
+		}
 
-			_, currOthVal, err := otherItr.Next()
+		if prev != nil {
+			isLess, err := curr.Less(nbf, prev)
 
 			if err != nil {
 				return false, err
 			}
 
-			if !currVal.Equals(currOthVal) {
-				return currVal.Less(nbf, currOthVal)
+			if isLess {
+				return false, nil
 			}
 		}
 
-		return itr.Len() < otherItr.Len(), nil
+		prev = curr
 	}
 
-	return TupleKind < other.Kind(), nil
+	return true, nil
 }
 
 // CountDifferencesBetweenTupleFields returns the number of fields that are different between two
@@ -476,6 +1649,194 @@ func (t Tuple) CountDifferencesBetweenTupleFields(other Tuple) (uint64, error) {
 	return uint64(changed), nil
 }
 
+// FirstDifference iterates over the fields of t and other in lockstep and returns the index of the first field at
+// which they differ. If the tuples agree on all of the fields they share, meaning one is a prefix of the other or they
+// are equal, differs is false and index is the number of shared fields. Fields after the first difference are not
+// decoded.
+func (t Tuple) FirstDifference(other Tuple) (index uint64, differs bool, err error) {
+	itr, err := t.Iterator()
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	otherItr, err := other.Iterator()
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	for itr.HasMore() && otherItr.HasMore() {
+		idx, val, err := itr.Next()
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		_, otherVal, err := otherItr.Next()
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		if !val.Equals(otherVal) {
+			return idx, true, nil
+		}
+	}
+
+	return itr.Pos(), false, nil
+}
+
+// TupleFieldDiff is a field which differs between two tuples.
+type TupleFieldDiff struct {
+	// Index is the index of the field
+	Index uint64
+
+	// Old is the value of the field in the first tuple, or nil if the first tuple has no field at Index
+	Old Value
+
+	// New is the value of the field in the second tuple, or nil if the second tuple has no field at Index
+	New Value
+}
+
+// DiffTupleFields iterates over the fields of a and b in lockstep and returns the fields which differ. When the tuples
+// have different lengths, the fields past the end of the shorter tuple are reported as additions (a nil Old) when b is
+// longer, or as removals (a nil New) when a is longer.
+func DiffTupleFields(nbf *NomsBinFormat, a, b Tuple) ([]TupleFieldDiff, error) {
+	aItr, err := a.Iterator()
+
+	if err != nil {
+		return nil, err
+	}
+
+	bItr, err := b.Iterator()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []TupleFieldDiff
+	for aItr.HasMore() || bItr.HasMore() {
+		var idx uint64
+		var aVal, bVal Value
+
+		if aItr.HasMore() {
+			idx, aVal, err = aItr.Next()
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if bItr.HasMore() {
+			idx, bVal, err = bItr.Next()
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if aVal == nil || bVal == nil || !aVal.Equals(bVal) {
+			diffs = append(diffs, TupleFieldDiff{Index: idx, Old: aVal, New: bVal})
+		}
+	}
+
+	return diffs, nil
+}
+
+// ThreeWayTupleDiff merges the field-wise changes made to base by ours and theirs. A field changed on only one side
+// takes that side's value, and a field changed identically on both sides takes the shared value. A field changed
+// differently on each side is a conflict: its index is returned in conflicts and the merged tuple keeps the base
+// value for it. The three tuples must have the same length.
+func ThreeWayTupleDiff(nbf *NomsBinFormat, base, ours, theirs Tuple) (merged Tuple, conflicts []uint64, err error) {
+	if base.Len() != ours.Len() || base.Len() != theirs.Len() {
+		return EmptyTuple(nbf), nil, errors.New("tuples must have the same length")
+	}
+
+	baseItr, err := base.Iterator()
+
+	if err != nil {
+		return EmptyTuple(nbf), nil, err
+	}
+
+	oursItr, err := ours.Iterator()
+
+	if err != nil {
+		return EmptyTuple(nbf), nil, err
+	}
+
+	theirsItr, err := theirs.Iterator()
+
+	if err != nil {
+		return EmptyTuple(nbf), nil, err
+	}
+
+	vals := make([]Value, 0, base.Len())
+	for baseItr.HasMore() {
+		idx, baseVal, err := baseItr.Next()
+
+		if err != nil {
+			return EmptyTuple(nbf), nil, err
+		}
+
+		_, oursVal, err := oursItr.Next()
+
+		if err != nil {
+			return EmptyTuple(nbf), nil, err
+		}
+
+		_, theirsVal, err := theirsItr.Next()
+
+		if err != nil {
+			return EmptyTuple(nbf), nil, err
+		}
+
+		switch {
+		case oursVal.Equals(theirsVal), theirsVal.Equals(baseVal):
+			vals = append(vals, oursVal)
+		case oursVal.Equals(baseVal):
+			vals = append(vals, theirsVal)
+		default:
+			vals = append(vals, baseVal)
+			conflicts = append(conflicts, idx)
+		}
+	}
+
+	merged, err = NewTuple(nbf, vals...)
+
+	if err != nil {
+		return EmptyTuple(nbf), nil, err
+	}
+
+	return merged, conflicts, nil
+}
+
+// TupleFieldTypeHistogram counts the kinds of the values found at each field position across tuples. The returned
+// slice has an entry for each position of the longest tuple, mapping each kind observed at that position to the number
+// of tuples with a value of that kind there. Shorter tuples only contribute to the positions they have. Only the kind
+// of each field is read, so the values themselves are not decoded.
+func TupleFieldTypeHistogram(nbf *NomsBinFormat, tuples []Tuple) ([]map[NomsKind]uint64, error) {
+	var hist []map[NomsKind]uint64
+	for _, t := range tuples {
+		dec, count := t.decoderSkipToFields()
+
+		for i := uint64(0); i < count; i++ {
+			if i == uint64(len(hist)) {
+				hist = append(hist, make(map[NomsKind]uint64))
+			}
+
+			hist[i][dec.peekKind()]++
+			err := dec.skipValue(nbf)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return hist, nil
+}
+
 func (t Tuple) fieldsToMap() (map[Value]Value, error) {
 	valMap := make(map[Value]Value)
 
@@ -504,6 +1865,21 @@ func (t Tuple) StartsWith(otherTuple Tuple) bool {
 	return bytes.HasPrefix(tplDec.buff[tplDec.offset:], otherDec.buff[otherDec.offset:])
 }
 
+// IsPrefixOf returns whether the fields of t are the leading fields of longer. It is equivalent to longer.StartsWith(t),
+// for call sites which hold the prefix.
+func (t Tuple) IsPrefixOf(longer Tuple) bool {
+	return longer.StartsWith(t)
+}
+
+// EqualsPrefix returns whether the fields of t and other are equal up to the length of the shorter of the two, ignoring
+// the trailing fields of the longer one. Unlike StartsWith, it does not matter which of the tuples is shorter. It is
+// meant for comparing rows written with an old schema to rows written after columns were appended to the schema, where
+// only the fields of the old columns can differ. Fields are compared by their encodings, so t and other must be in the
+// same format.
+func (t Tuple) EqualsPrefix(other Tuple) bool {
+	return t.StartsWith(other) || other.StartsWith(t)
+}
+
 func (t Tuple) readFrom(nbf *NomsBinFormat, b *binaryNomsReader) (Value, error) {
 	panic("unreachable")
 }
@@ -516,6 +1892,47 @@ func (t Tuple) String() string {
 	panic("unreachable")
 }
 
+// FormatWith renders the tuple as a parenthesized, comma separated list of its fields, like EncodedValue. Each field whose
+// kind has a function in fns is rendered by that function. Nested tuples without a function of their own are rendered
+// by FormatWith with the same functions, and all other fields are rendered by EncodedValue.
+func (t Tuple) FormatWith(fns map[NomsKind]func(Value) string) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("(")
+
+	err := t.IterFields(func(i uint64, v Value) (bool, error) {
+		if i != 0 {
+			sb.WriteString(",")
+		}
+
+		if fn, ok := fns[v.Kind()]; ok {
+			sb.WriteString(fn(v))
+			return false, nil
+		}
+
+		var str string
+		var err error
+		if nested, ok := v.(Tuple); ok {
+			str, err = nested.FormatWith(fns)
+		} else {
+			str, err = EncodedValue(context.Background(), v)
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		sb.WriteString(str)
+		return false, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	sb.WriteString(")")
+	return sb.String(), nil
+}
+
 func (t Tuple) HumanReadableString() string {
 	panic("unreachable")
 }
@@ -0,0 +1,111 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+// TupleComparer orders tuples field by field, such as the keys of an index, where the direction of each field and the
+// position of NULL fields are chosen by the index. A NULL field is represented by NullValue. NULLs are ordered before
+// all other values when NullsFirst is true, and after them when it is false, regardless of the direction of the
+// field. Only the fields of the tuples are treated specially; nested values are ordered as they are by Less. When one
+// tuple is a prefix of the other, the shorter tuple is ordered first. The zero TupleComparer orders tuples like Less,
+// except that NULLs are ordered last.
+type TupleComparer struct {
+	// NullsFirst orders NULL fields before all other values when true, and after them when false.
+	NullsFirst bool
+
+	// Descending orders field i in descending order when Descending[i] is true. Fields past the end of Descending are
+	// ordered in ascending order.
+	Descending []bool
+}
+
+// Compare returns a negative number if a is ordered before b, a positive number if a is ordered after b, and 0 if they
+// are equal. Fields are compared in the NomsBinFormat of a.
+func (tc TupleComparer) Compare(a, b Tuple) (int, error) {
+	return tc.compare(a.format(), a, b)
+}
+
+// Less returns whether a is ordered before b.
+func (tc TupleComparer) Less(a, b Tuple) (bool, error) {
+	c, err := tc.Compare(a, b)
+
+	if err != nil {
+		return false, err
+	}
+
+	return c < 0, nil
+}
+
+func (tc TupleComparer) compare(nbf *NomsBinFormat, a, b Tuple) (int, error) {
+	itr, err := a.Iterator()
+
+	if err != nil {
+		return 0, err
+	}
+
+	otherItr, err := b.Iterator()
+
+	if err != nil {
+		return 0, err
+	}
+
+	for itr.HasMore() && otherItr.HasMore() {
+		i, currVal, err := itr.Next()
+
+		if err != nil {
+			return 0, err
+		}
+
+		_, currOthVal, err := otherItr.Next()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if currVal.Equals(currOthVal) {
+			continue
+		}
+
+		isNull, othIsNull := IsNull(currVal), IsNull(currOthVal)
+
+		if isNull || othIsNull {
+			// exactly one of the fields is NULL, as the fields are not equal
+			if isNull == tc.NullsFirst {
+				return -1, nil
+			}
+
+			return 1, nil
+		}
+
+		c, err := DefaultFieldComparator(nbf, currVal, currOthVal)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if i < uint64(len(tc.Descending)) && tc.Descending[i] {
+			c = -c
+		}
+
+		return c, nil
+	}
+
+	switch {
+	case itr.Len() < otherItr.Len():
+		return -1, nil
+	case itr.Len() > otherItr.Len():
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTupleComparer(t *testing.T) {
+	null := mustTuple(NewTuple(Format_7_18, NullValue))
+	one := mustTuple(NewTuple(Format_7_18, Int(1)))
+	two := mustTuple(NewTuple(Format_7_18, Int(2)))
+
+	tests := []struct {
+		name     string
+		tc       TupleComparer
+		expected []Tuple
+	}{
+		{"ascending nulls first", TupleComparer{NullsFirst: true}, []Tuple{null, one, two}},
+		{"ascending nulls last", TupleComparer{NullsFirst: false}, []Tuple{one, two, null}},
+		{"descending nulls first", TupleComparer{NullsFirst: true, Descending: []bool{true}}, []Tuple{null, two, one}},
+		{"descending nulls last", TupleComparer{NullsFirst: false, Descending: []bool{true}}, []Tuple{two, one, null}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, a := range test.expected {
+				for j, b := range test.expected {
+					c, err := test.tc.Compare(a, b)
+					require.NoError(t, err)
+
+					switch {
+					case i < j:
+						assert.True(t, c < 0, "i: %d, j: %d", i, j)
+					case i > j:
+						assert.True(t, c > 0, "i: %d, j: %d", i, j)
+					default:
+						assert.Equal(t, 0, c, "i: %d, j: %d", i, j)
+					}
+
+					isLess, err := test.tc.Less(a, b)
+					require.NoError(t, err)
+					assert.Equal(t, i < j, isLess, "i: %d, j: %d", i, j)
+				}
+			}
+		})
+	}
+}
+
+func TestTupleComparerMixedDirections(t *testing.T) {
+	tc := TupleComparer{NullsFirst: false, Descending: []bool{false, true}}
+
+	// the first field is ascending and the second descending, with NULLs last in both. The
+	// third field has no direction, so it is ascending.
+	expected := []Tuple{
+		mustTuple(NewTuple(Format_7_18, Int(1))),
+		mustTuple(NewTuple(Format_7_18, Int(1), String("b"))),
+		mustTuple(NewTuple(Format_7_18, Int(1), String("a"), Int(1))),
+		mustTuple(NewTuple(Format_7_18, Int(1), String("a"), Int(2))),
+		mustTuple(NewTuple(Format_7_18, Int(1), String("a"), NullValue)),
+		mustTuple(NewTuple(Format_7_18, Int(1), NullValue)),
+		mustTuple(NewTuple(Format_7_18, Int(2), String("a"))),
+		mustTuple(NewTuple(Format_7_18, NullValue, String("b"))),
+		mustTuple(NewTuple(Format_7_18, NullValue, String("a"))),
+	}
+
+	for i, a := range expected {
+		for j, b := range expected {
+			isLess, err := tc.Less(a, b)
+			require.NoError(t, err)
+			assert.Equal(t, i < j, isLess, "i: %d, j: %d", i, j)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/liquidata-inc/dolt/go/store/util/sizecache"
+)
+
+// TupleDecodeCache caches the decoded fields of tuples, keyed by the hash of their contents. It holds the fields of up
+// to a fixed number of tuples, evicting the least recently used tuple when full. Code which repeatedly reads fields
+// from a small working set of tuples, such as lookups in a dictionary-like map, can use it to avoid decoding the same
+// tuples again and again. A TupleDecodeCache is safe for concurrent use.
+type TupleDecodeCache struct {
+	cache *sizecache.SizeCache
+}
+
+// NewTupleDecodeCache creates a TupleDecodeCache holding the fields of at most maxTuples tuples.
+func NewTupleDecodeCache(maxTuples uint64) *TupleDecodeCache {
+	return &TupleDecodeCache{sizecache.New(maxTuples)}
+}
+
+// Get returns the fields of t, decoding them only if they are not already cached. The returned slice is shared with
+// the cache and must not be modified.
+func (c *TupleDecodeCache) Get(t Tuple) ([]Value, error) {
+	h, err := t.Hash(t.format())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if vals, ok := c.cache.Get(h); ok {
+		return vals.([]Value), nil
+	}
+
+	vals := make([]Value, 0, t.Len())
+	err = t.IterFields(func(index uint64, value Value) (stop bool, err error) {
+		vals = append(vals, value)
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.Add(h, 1, vals)
+
+	return vals, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTupleDecodeCache(t *testing.T) {
+	a := mustTuple(NewTuple(Format_7_18, Int(1), String("a"), NullValue))
+	b := mustTuple(NewTuple(Format_7_18, Int(2), String("b")))
+	c := mustTuple(NewTuple(Format_7_18, Int(3)))
+
+	cache := NewTupleDecodeCache(2)
+
+	vals, err := cache.Get(a)
+	require.NoError(t, err)
+	assert.Equal(t, []Value{Int(1), String("a"), NullValue}, vals)
+
+	_, err = cache.Get(b)
+	require.NoError(t, err)
+
+	// a is used more recently than b, so b is evicted when c is added
+	_, err = cache.Get(a)
+	require.NoError(t, err)
+	vals, err = cache.Get(c)
+	require.NoError(t, err)
+	assert.Equal(t, []Value{Int(3)}, vals)
+
+	for _, tpl := range []Tuple{a, b, c} {
+		h, err := tpl.Hash(Format_7_18)
+		require.NoError(t, err)
+		_, ok := cache.cache.Get(h)
+		assert.Equal(t, !tpl.Equals(b), ok)
+	}
+
+	vals, err = cache.Get(b)
+	require.NoError(t, err)
+	assert.Equal(t, []Value{Int(2), String("b")}, vals)
+
+	vals, err = cache.Get(EmptyTuple(Format_7_18))
+	require.NoError(t, err)
+	assert.Empty(t, vals)
+}
+
+func makeBenchmarkTuples(b *testing.B, n, fields int) []Tuple {
+	tuples := make([]Tuple, n)
+	for i := range tuples {
+		vals := make([]Value, fields)
+		for j := range vals {
+			vals[j] = String(fmt.Sprintf("%d-%d", i, j))
+		}
+
+		var err error
+		tuples[i], err = NewTuple(Format_7_18, vals...)
+		require.NoError(b, err)
+	}
+	return tuples
+}
+
+// BenchmarkTupleGetRandomField reads random fields from a small working set of tuples, decoding each field as it is read.
+func BenchmarkTupleGetRandomField(b *testing.B) {
+	const fields = 32
+	tuples := makeBenchmarkTuples(b, 100, fields)
+	rnd := rand.New(rand.NewSource(0))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tpl := tuples[rnd.Intn(len(tuples))]
+		_, err := tpl.Get(uint64(rnd.Intn(fields)))
+		require.NoError(b, err)
+	}
+}
+
+// BenchmarkTupleDecodeCacheRandomField reads random fields from the same working set of tuples using a
+// TupleDecodeCache large enough to hold all of them.
+func BenchmarkTupleDecodeCacheRandomField(b *testing.B) {
+	const fields = 32
+	tuples := makeBenchmarkTuples(b, 100, fields)
+	cache := NewTupleDecodeCache(uint64(len(tuples)))
+	rnd := rand.New(rand.NewSource(0))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tpl := tuples[rnd.Intn(len(tuples))]
+		vals, err := cache.Get(tpl)
+		require.NoError(b, err)
+		_ = vals[rnd.Intn(fields)]
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "sync"
+
+var tupleIteratorPool = sync.Pool{
+	New: func() interface{} {
+		return &TupleIterator{}
+	},
+}
+
+// AcquireIterator returns an iterator over the fields of t, like Iterator, which may reuse an iterator that was
+// released with ReleaseIterator rather than allocating a new one. Code which creates and discards many iterators, such
+// as a scan over the fields of every tuple in a map, can use it to avoid allocating an iterator per tuple. The iterator
+// should be passed to ReleaseIterator once it is no longer used.
+func (t Tuple) AcquireIterator() (*TupleIterator, error) {
+	itr := tupleIteratorPool.Get().(*TupleIterator)
+	err := t.initIterator(itr, 0)
+
+	if err != nil {
+		ReleaseIterator(itr)
+		return nil, err
+	}
+
+	return itr, nil
+}
+
+// ReleaseIterator returns itr to the pool used by AcquireIterator. itr must not be used after it is released. Its
+// reference to the tuple it was iterating over is cleared, so a pooled iterator does not keep the tuple's data alive.
+func ReleaseIterator(itr *TupleIterator) {
+	*itr = TupleIterator{}
+	tupleIteratorPool.Put(itr)
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTupleAcquireIterator(t *testing.T) {
+	tuples := []Tuple{
+		mustTuple(NewTuple(Format_7_18, Int(1), String("one"), NullValue)),
+		mustTuple(NewTuple(Format_7_18)),
+		mustTuple(NewTuple(Format_7_18, Bool(true), Float(2.5))),
+	}
+
+	for _, tpl := range tuples {
+		expected, err := tpl.Iterator()
+		require.NoError(t, err)
+
+		itr, err := tpl.AcquireIterator()
+		require.NoError(t, err)
+		assert.Equal(t, tpl.Len(), itr.Len())
+		assert.Equal(t, uint64(0), itr.Pos())
+
+		for expected.HasMore() {
+			require.True(t, itr.HasMore())
+			i, v, err := itr.Next()
+			require.NoError(t, err)
+			expectedI, expectedV, err := expected.Next()
+			require.NoError(t, err)
+			assert.Equal(t, expectedI, i)
+			assert.True(t, expectedV.Equals(v))
+		}
+		assert.False(t, itr.HasMore())
+
+		ReleaseIterator(itr)
+		assert.Equal(t, TupleIterator{}, *itr)
+	}
+}
+
+func makeBenchmarkIntTuples(n, fields int) []Tuple {
+	tuples := make([]Tuple, n)
+	for i := range tuples {
+		vals := make([]Value, fields)
+		for j := range vals {
+			vals[j] = Int(j)
+		}
+		tuples[i] = mustTuple(NewTuple(Format_7_18, vals...))
+	}
+	return tuples
+}
+
+// BenchmarkTupleIteratorScan iterates over the fields of many small tuples, allocating an iterator for each tuple.
+func BenchmarkTupleIteratorScan(b *testing.B) {
+	tuples := makeBenchmarkIntTuples(1000, 4)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tpl := range tuples {
+			itr, err := tpl.Iterator()
+			require.NoError(b, err)
+			for itr.HasMore() {
+				_, _, err = itr.Next()
+				require.NoError(b, err)
+			}
+		}
+	}
+}
+
+// BenchmarkTupleAcquireIteratorScan iterates over the same tuples as BenchmarkTupleIteratorScan, reusing pooled
+// iterators.
+func BenchmarkTupleAcquireIteratorScan(b *testing.B) {
+	tuples := makeBenchmarkIntTuples(1000, 4)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, tpl := range tuples {
+			itr, err := tpl.AcquireIterator()
+			require.NoError(b, err)
+			for itr.HasMore() {
+				_, _, err = itr.Next()
+				require.NoError(b, err)
+			}
+			ReleaseIterator(itr)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"context"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/store/atomicerr"
+)
+
+// parallelDecodeMinFields is the number of fields below which FieldsParallel decodes sequentially, as the cost of
+// starting goroutines outweighs the cost of decoding.
+const parallelDecodeMinFields = 64
+
+// FieldsParallel decodes all of the fields of the tuple using up to workers goroutines. The offsets of the fields are
+// found in a single pass over the tuple, and then each worker decodes a contiguous range of fields. Tuples with fewer
+// than 64 fields, or a workers count below 2, are decoded sequentially.
+func (t Tuple) FieldsParallel(ctx context.Context, workers int) ([]Value, error) {
+	dec, count := t.decoderSkipToFields()
+	vals := make([]Value, count)
+
+	if workers < 2 || count < parallelDecodeMinFields {
+		for i := range vals {
+			v, err := dec.readValue(t.format())
+
+			if err != nil {
+				return nil, err
+			}
+
+			vals[i] = v
+		}
+
+		return vals, nil
+	}
+
+	offsets := make([]uint32, count)
+	for i := range offsets {
+		offsets[i] = dec.offset
+		err := dec.skipValue(t.format())
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if uint64(workers) > count {
+		workers = int(count)
+	}
+
+	ae := atomicerr.New()
+	wg := &sync.WaitGroup{}
+	perWorker := (count + uint64(workers) - 1) / uint64(workers)
+
+	for start := uint64(0); start < count; start += perWorker {
+		end := start + perWorker
+		if end > count {
+			end = count
+		}
+
+		wg.Add(1)
+		go func(start, end uint64) {
+			defer wg.Done()
+
+			workerDec := t.decoder()
+			workerDec.offset = offsets[start]
+
+			for i := start; i < end; i++ {
+				if ae.IsSet() || ae.SetIfError(ctx.Err()) {
+					return
+				}
+
+				v, err := workerDec.readValue(t.format())
+
+				if ae.SetIfError(err) {
+					return
+				}
+
+				vals[i] = v
+			}
+		}(start, end)
+	}
+
+	wg.Wait()
+
+	if err := ae.Get(); err != nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
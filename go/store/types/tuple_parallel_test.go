@@ -0,0 +1,91 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeWideTuple returns a tuple of n fields of mixed types.
+func makeWideTuple(n int) Tuple {
+	vals := make([]Value, n)
+	for i := range vals {
+		switch i % 5 {
+		case 0:
+			vals[i] = Int(i)
+		case 1:
+			vals[i] = String("field value")
+		case 2:
+			vals[i] = Float(float64(i) / 3)
+		case 3:
+			vals[i] = NullValue
+		default:
+			vals[i] = mustTuple(NewTuple(Format_7_18, Uint(i), Bool(true)))
+		}
+	}
+
+	return mustTuple(NewTuple(Format_7_18, vals...))
+}
+
+func TestTupleFieldsParallel(t *testing.T) {
+	ctx := context.Background()
+
+	for _, n := range []int{0, 1, parallelDecodeMinFields - 1, parallelDecodeMinFields, 1000} {
+		tpl := makeWideTuple(n)
+
+		for _, workers := range []int{0, 1, 3, 8, 2000} {
+			vals, err := tpl.FieldsParallel(ctx, workers)
+			require.NoError(t, err)
+			require.Len(t, vals, n)
+
+			for i, v := range vals {
+				expected, err := tpl.Get(uint64(i))
+				require.NoError(t, err)
+				assert.True(t, expected.Equals(v), "field %d of %d with %d workers", i, n, workers)
+			}
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := makeWideTuple(1000).FieldsParallel(canceled, 4)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func BenchmarkTupleFieldsSequential(b *testing.B) {
+	ctx := context.Background()
+	tpl := makeWideTuple(1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := tpl.FieldsParallel(ctx, 1)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkTupleFieldsParallel(b *testing.B) {
+	ctx := context.Background()
+	tpl := makeWideTuple(1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := tpl.FieldsParallel(ctx, 4)
+		require.NoError(b, err)
+	}
+}
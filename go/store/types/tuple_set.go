@@ -0,0 +1,106 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"context"
+	"io"
+)
+
+// TupleSet is an ordered set of Tuples. It is backed by a Map whose keys are the members of the set and whose values
+// are all NullValue, so members are ordered by Tuple.Less. Like Map, a TupleSet is immutable and Insert returns a new
+// TupleSet.
+type TupleSet struct {
+	m Map
+}
+
+// NewTupleSet creates a TupleSet containing the given tuples.
+func NewTupleSet(ctx context.Context, vrw ValueReadWriter, tuples ...Tuple) (TupleSet, error) {
+	m, err := NewMap(ctx, vrw)
+
+	if err != nil {
+		return TupleSet{}, err
+	}
+
+	return TupleSet{m}.Insert(ctx, tuples...)
+}
+
+// Insert returns a new TupleSet with the given tuples added. Tuples already in the set are ignored.
+func (ts TupleSet) Insert(ctx context.Context, tuples ...Tuple) (TupleSet, error) {
+	if len(tuples) == 0 {
+		return ts, nil
+	}
+
+	me := ts.m.Edit()
+
+	for _, t := range tuples {
+		me.Set(t, NullValue)
+	}
+
+	m, err := me.Map(ctx)
+
+	if err != nil {
+		return TupleSet{}, err
+	}
+
+	return TupleSet{m}, nil
+}
+
+// Contains returns whether t is a member of the set.
+func (ts TupleSet) Contains(ctx context.Context, t Tuple) (bool, error) {
+	return ts.m.Has(ctx, t)
+}
+
+// Len returns the number of tuples in the set.
+func (ts TupleSet) Len() uint64 {
+	return ts.m.Len()
+}
+
+// Map returns the Map backing the set.
+func (ts TupleSet) Map() Map {
+	return ts.m
+}
+
+// Iterator returns an iterator over the members of the set in ascending order.
+func (ts TupleSet) Iterator(ctx context.Context) (*TupleSetIterator, error) {
+	itr, err := ts.m.Iterator(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &TupleSetIterator{itr, ts.m.Format()}, nil
+}
+
+// TupleSetIterator iterates over the members of a TupleSet.
+type TupleSetIterator struct {
+	itr MapIterator
+	nbf *NomsBinFormat
+}
+
+// Next returns the next tuple in the set, or io.EOF once all tuples have been returned.
+func (itr *TupleSetIterator) Next(ctx context.Context) (Tuple, error) {
+	k, _, err := itr.itr.Next(ctx)
+
+	if err != nil {
+		return EmptyTuple(itr.nbf), err
+	}
+
+	if k == nil {
+		return EmptyTuple(itr.nbf), io.EOF
+	}
+
+	return k.(Tuple), nil
+}
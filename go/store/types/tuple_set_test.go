@@ -0,0 +1,77 @@
+// Copyright 2020 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTupleSet(t *testing.T) {
+	ctx := context.Background()
+	vrw := newTestValueStore()
+
+	a := mustTuple(NewTuple(Format_7_18, Int(1), String("a")))
+	b := mustTuple(NewTuple(Format_7_18, Int(1), String("b")))
+	c := mustTuple(NewTuple(Format_7_18, Int(2)))
+	missing := mustTuple(NewTuple(Format_7_18, Int(3)))
+
+	ts, err := NewTupleSet(ctx, vrw, c, a)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), ts.Len())
+
+	ts2, err := ts.Insert(ctx, b, a)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), ts.Len())
+	assert.Equal(t, uint64(3), ts2.Len())
+
+	for _, tpl := range []Tuple{a, b, c} {
+		ok, err := ts2.Contains(ctx, tpl)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	ok, err := ts2.Contains(ctx, missing)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = ts.Contains(ctx, b)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	itr, err := ts2.Iterator(ctx)
+	require.NoError(t, err)
+
+	var actual []Tuple
+	for {
+		tpl, err := itr.Next(ctx)
+
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+		actual = append(actual, tpl)
+	}
+
+	require.Len(t, actual, 3)
+	assert.True(t, a.Equals(actual[0]))
+	assert.True(t, b.Equals(actual[1]))
+	assert.True(t, c.Equals(actual[2]))
+}
@@ -16,7 +16,11 @@ package types
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -282,6 +286,1478 @@ func TestTupleStartsWith(t *testing.T) {
 			} else {
 				assert.False(t, tpl1.StartsWith(tpl2))
 			}
+			assert.Equal(t, tpl1.StartsWith(tpl2), tpl2.IsPrefixOf(tpl1))
 		})
 	}
 }
+
+func TestTupleEqualsPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		shorter  []Value
+		longer   []Value
+		expected bool
+	}{
+		{"equal", []Value{Int(1), String("a")}, []Value{Int(1), String("a")}, true},
+		{"appended fields", []Value{Int(1), String("a")}, []Value{Int(1), String("a"), NullValue, Uint(7)}, true},
+		{"empty", []Value{}, []Value{Int(1)}, true},
+		{"different field", []Value{Int(1), String("a")}, []Value{Int(1), String("b"), Uint(7)}, false},
+		{"different first field", []Value{Int(2)}, []Value{Int(1), Int(2)}, false},
+		{"same kind and value but different length", []Value{String("ab")}, []Value{String("abc"), Int(1)}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shorter := mustTuple(NewTuple(Format_7_18, test.shorter...))
+			longer := mustTuple(NewTuple(Format_7_18, test.longer...))
+			assert.Equal(t, test.expected, shorter.EqualsPrefix(longer))
+			assert.Equal(t, test.expected, longer.EqualsPrefix(shorter))
+		})
+	}
+}
+
+func TestTupleAsMap(t *testing.T) {
+	tests := [][]Value{
+		{},
+		{Int(1)},
+		{String("abc"), Int(1234), String("abc"), NullValue, Uint(67)},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v", test), func(t *testing.T) {
+			tpl, err := NewTuple(Format_7_18, test...)
+			require.NoError(t, err)
+
+			fields, err := tpl.AsMap()
+			require.NoError(t, err)
+			require.Equal(t, int(tpl.Len()), len(fields))
+
+			for i, v := range test {
+				actual, ok := fields[uint64(i)]
+				require.True(t, ok)
+				assert.True(t, v.Equals(actual))
+			}
+
+			// modifying the snapshot does not change the tuple
+			fields[0] = String("modified")
+			if len(test) > 0 {
+				actual, err := tpl.Get(0)
+				require.NoError(t, err)
+				assert.True(t, test[0].Equals(actual))
+			}
+		})
+	}
+}
+
+func TestTupleFillDefaults(t *testing.T) {
+	defaults := []Value{Int(-1), String("default"), NullValue, Bool(false)}
+	tpl := mustTuple(NewTuple(Format_7_18, Int(1), String("abc")))
+
+	filled, err := tpl.FillDefaults(4, defaults)
+	require.NoError(t, err)
+	expected := mustTuple(NewTuple(Format_7_18, Int(1), String("abc"), NullValue, Bool(false)))
+	assert.True(t, expected.Equals(filled))
+
+	filled, err = tpl.FillDefaults(3, defaults)
+	require.NoError(t, err)
+	expected = mustTuple(NewTuple(Format_7_18, Int(1), String("abc"), NullValue))
+	assert.True(t, expected.Equals(filled))
+
+	filled, err = EmptyTuple(Format_7_18).FillDefaults(4, defaults)
+	require.NoError(t, err)
+	assert.True(t, mustTuple(NewTuple(Format_7_18, defaults...)).Equals(filled))
+
+	// tuples with at least arity fields are unchanged, even if there are too few defaults
+	for _, arity := range []uint64{0, 1, 2} {
+		filled, err = tpl.FillDefaults(arity, nil)
+		require.NoError(t, err)
+		assert.True(t, tpl.Equals(filled))
+	}
+
+	_, err = tpl.FillDefaults(5, defaults)
+	assert.Error(t, err)
+
+	// the original tuple is not modified
+	assert.Equal(t, uint64(2), tpl.Len())
+}
+
+func TestTupleAppendIfChanged(t *testing.T) {
+	tests := []struct {
+		initial  []Value
+		toAppend Value
+		appended bool
+	}{
+		{[]Value{}, Int(1), true},
+		{[]Value{Int(1)}, Int(1), false},
+		{[]Value{Int(1)}, Int(2), true},
+		{[]Value{Int(1)}, Uint(1), true},
+		{[]Value{String("abc"), Int(1), String("abc")}, String("abc"), false},
+		{[]Value{String("abc"), Int(1), String("abc")}, Int(1), true},
+		{[]Value{Int(1), NullValue}, NullValue, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v|%v", test.initial, test.toAppend), func(t *testing.T) {
+			tpl, err := NewTuple(Format_7_18, test.initial...)
+			require.NoError(t, err)
+
+			res, appended, err := tpl.AppendIfChanged(test.toAppend)
+			require.NoError(t, err)
+			assert.Equal(t, test.appended, appended)
+
+			if test.appended {
+				expected, err := NewTuple(Format_7_18, append(test.initial, test.toAppend)...)
+				require.NoError(t, err)
+				assert.True(t, expected.Equals(res))
+			} else {
+				assert.True(t, tpl.Equals(res))
+			}
+		})
+	}
+}
+
+func TestWriteTupleMatchesNewTuple(t *testing.T) {
+	tests := [][]Value{
+		{},
+		{Int(1)},
+		{String("abc"), Int(-1234), NullValue, Uint(67), Float(1.5), Bool(true)},
+		{mustTuple(NewTuple(Format_7_18, Int(1), String("nested"))), InlineBlob{1, 2, 3}},
+	}
+
+	for _, vals := range tests {
+		expected := mustTuple(NewTuple(Format_7_18, vals...))
+
+		// write the tuple after other data, as when it is part of a larger structure
+		w := newBinaryNomsWriter()
+		w.writeString("prefix")
+		start := w.offset
+		err := WriteTuple(&w, Format_7_18, vals...)
+		require.NoError(t, err)
+		assert.Equal(t, expected.buff, w.data()[start:])
+
+		// a second tuple follows the first
+		err = WriteTuple(&w, Format_7_18, vals...)
+		require.NoError(t, err)
+		assert.Equal(t, append(append([]byte{}, expected.buff...), expected.buff...), w.data()[start:])
+	}
+}
+
+func TestNewFixedTuple(t *testing.T) {
+	tests := []struct {
+		arity  int
+		values []Value
+		valid  bool
+	}{
+		{0, []Value{}, true},
+		{1, []Value{Int(1)}, true},
+		{2, []Value{Int(1), String("abc")}, true},
+		{2, []Value{Int(1)}, false},
+		{2, []Value{Int(1), String("abc"), NullValue}, false},
+		{0, []Value{Int(1)}, false},
+		{-1, []Value{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d %v", test.arity, test.values), func(t *testing.T) {
+			tpl, err := NewFixedTuple(Format_7_18, test.arity, test.values...)
+
+			if !test.valid {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			expected, err := NewTuple(Format_7_18, test.values...)
+			require.NoError(t, err)
+			assert.True(t, expected.Equals(tpl))
+		})
+	}
+}
+
+func TestNewTupleOfTypes(t *testing.T) {
+	tests := []struct {
+		expected []*Type
+		values   []Value
+		valid    bool
+	}{
+		{[]*Type{}, []Value{}, true},
+		{[]*Type{PrimitiveTypeMap[IntKind]}, []Value{Int(1)}, true},
+		{[]*Type{PrimitiveTypeMap[IntKind]}, []Value{Uint(1)}, false},
+		{[]*Type{PrimitiveTypeMap[UintKind], PrimitiveTypeMap[StringKind]}, []Value{Uint(1), String("abc")}, true},
+		{[]*Type{PrimitiveTypeMap[UintKind], PrimitiveTypeMap[StringKind]}, []Value{Uint(1), Int(1)}, false},
+		{[]*Type{nil, PrimitiveTypeMap[StringKind]}, []Value{Int(1), String("abc")}, true},
+		{[]*Type{nil, nil}, []Value{Bool(true), Float(1.5)}, true},
+		{[]*Type{PrimitiveTypeMap[IntKind]}, []Value{Int(1), Int(2)}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v", test.values), func(t *testing.T) {
+			tpl, err := NewTupleOfTypes(Format_7_18, test.expected, test.values...)
+
+			if !test.valid {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			expected, err := NewTuple(Format_7_18, test.values...)
+			require.NoError(t, err)
+			assert.True(t, expected.Equals(tpl))
+		})
+	}
+}
+
+func TestTupleCountFields(t *testing.T) {
+	notNull := func(index uint64, v Value) bool {
+		return !IsNull(v)
+	}
+	evenIndex := func(index uint64, v Value) bool {
+		return index%2 == 0
+	}
+
+	tests := []struct {
+		values   []Value
+		pred     func(index uint64, v Value) bool
+		expected uint64
+	}{
+		{[]Value{}, nil, 0},
+		{[]Value{}, notNull, 0},
+		{[]Value{Int(1), NullValue, String("abc")}, nil, 3},
+		{[]Value{Int(1), NullValue, String("abc")}, notNull, 2},
+		{[]Value{NullValue, NullValue}, notNull, 0},
+		{[]Value{Int(1), Int(2), Int(3), Int(4), Int(5)}, evenIndex, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v", test.values), func(t *testing.T) {
+			tpl, err := NewTuple(Format_7_18, test.values...)
+			require.NoError(t, err)
+
+			n, err := tpl.CountFields(test.pred)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, n)
+		})
+	}
+}
+
+func TestTuplePrefixHash(t *testing.T) {
+	values := []Value{String("abc"), Int(1234), NullValue, Uint(67), InlineBlob{1, 2, 3}}
+	tpl, err := NewTuple(Format_7_18, values...)
+	require.NoError(t, err)
+
+	for k := 0; k <= len(values); k++ {
+		t.Run(fmt.Sprintf("prefix %d", k), func(t *testing.T) {
+			prefix, err := NewTuple(Format_7_18, values[:k]...)
+			require.NoError(t, err)
+			expected, err := prefix.Hash(Format_7_18)
+			require.NoError(t, err)
+
+			actual, err := tpl.PrefixHash(Format_7_18, uint64(k))
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+
+	assert.Panics(t, func() {
+		_, _ = tpl.PrefixHash(Format_7_18, uint64(len(values)+1))
+	})
+}
+
+func TestTupleAppendEncoded(t *testing.T) {
+	encode := func(v Value) []byte {
+		w := newBinaryNomsWriter()
+		err := v.writeTo(&w, Format_7_18)
+		require.NoError(t, err)
+		return w.data()
+	}
+
+	initial, err := NewTuple(Format_7_18, String("abc"), Int(1234))
+	require.NoError(t, err)
+
+	values := []Value{Int(-1), Uint(1234), String("hello"), NullValue, InlineBlob{1, 2, 3}, UUID(uuid.MustParse(OneUUID))}
+	for _, v := range values {
+		t.Run(fmt.Sprintf("%v", v), func(t *testing.T) {
+			expected, err := initial.Append(v)
+			require.NoError(t, err)
+
+			actual, err := initial.AppendEncoded(encode(v))
+			require.NoError(t, err)
+			assert.True(t, expected.Equals(actual))
+
+			last, err := actual.Get(actual.Len() - 1)
+			require.NoError(t, err)
+			assert.True(t, v.Equals(last))
+		})
+	}
+
+	_, err = initial.AppendEncoded(nil)
+	assert.Equal(t, ErrInvalidEncodedValue, err)
+
+	_, err = initial.AppendEncoded(append(encode(Int(1)), encode(Int(2))...))
+	assert.Equal(t, ErrInvalidEncodedValue, err)
+}
+
+func TestTupleSplitAt(t *testing.T) {
+	values := []Value{String("abc"), Int(1234), NullValue, Uint(67), InlineBlob{1, 2, 3}}
+	tpl, err := NewTuple(Format_7_18, values...)
+	require.NoError(t, err)
+
+	for n := 0; n <= len(values); n++ {
+		t.Run(fmt.Sprintf("split at %d", n), func(t *testing.T) {
+			expectedHead, err := NewTuple(Format_7_18, values[:n]...)
+			require.NoError(t, err)
+			expectedTail, err := NewTuple(Format_7_18, values[n:]...)
+			require.NoError(t, err)
+
+			head, tail, err := tpl.SplitAt(uint64(n))
+			require.NoError(t, err)
+			assert.True(t, expectedHead.Equals(head))
+			assert.True(t, expectedTail.Equals(tail))
+			assert.Equal(t, uint64(n), head.Len())
+			assert.Equal(t, uint64(len(values)-n), tail.Len())
+		})
+	}
+
+	assert.Panics(t, func() {
+		_, _, _ = tpl.SplitAt(uint64(len(values) + 1))
+	})
+}
+
+func TestTupleProject(t *testing.T) {
+	nbf := Format_7_18
+	tpl := mustTuple(NewTuple(nbf, Int(0), String("one"), NullValue, Float(3.5), Bool(true)))
+
+	tests := []struct {
+		name     string
+		indices  []uint64
+		expected []Value
+	}{
+		{"all fields", []uint64{0, 1, 2, 3, 4}, []Value{Int(0), String("one"), NullValue, Float(3.5), Bool(true)}},
+		{"subset", []uint64{1, 3}, []Value{String("one"), Float(3.5)}},
+		{"reordered", []uint64{4, 0, 2}, []Value{Bool(true), Int(0), NullValue}},
+		{"duplicated", []uint64{1, 1, 0, 1}, []Value{String("one"), String("one"), Int(0), String("one")}},
+		{"no fields", nil, []Value{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projected, err := tpl.Project(test.indices...)
+			require.NoError(t, err)
+			expected := mustTuple(NewTuple(nbf, test.expected...))
+			assert.True(t, expected.Equals(projected))
+		})
+	}
+
+	all, err := tpl.Project(0, 1, 2, 3, 4)
+	require.NoError(t, err)
+	assert.Equal(t, tpl.buff, all.buff)
+
+	assert.Panics(t, func() {
+		_, _ = tpl.Project(0, 5)
+	})
+}
+
+func TestTupleReplaceRange(t *testing.T) {
+	values := []Value{String("abc"), Int(1234), NullValue, Uint(67)}
+	tpl := mustTuple(NewTuple(Format_7_18, values...))
+
+	tests := []struct {
+		name     string
+		start    uint64
+		end      uint64
+		values   []Value
+		expected []Value
+	}{
+		{"replace one", 1, 2, []Value{Int(5)}, []Value{String("abc"), Int(5), NullValue, Uint(67)}},
+		{"grow", 0, 2, []Value{Int(1), Int(2), Int(3)}, []Value{Int(1), Int(2), Int(3), NullValue, Uint(67)}},
+		{"shrink", 1, 4, []Value{Bool(true)}, []Value{String("abc"), Bool(true)}},
+		{"insert at start", 0, 0, []Value{Int(0)}, []Value{Int(0), String("abc"), Int(1234), NullValue, Uint(67)}},
+		{"insert in middle", 2, 2, []Value{Int(0), Int(1)}, []Value{String("abc"), Int(1234), Int(0), Int(1), NullValue, Uint(67)}},
+		{"insert at end", 4, 4, []Value{Int(0)}, []Value{String("abc"), Int(1234), NullValue, Uint(67), Int(0)}},
+		{"delete", 1, 3, nil, []Value{String("abc"), Uint(67)}},
+		{"delete all", 0, 4, nil, nil},
+		{"replace all", 0, 4, []Value{String("xyz")}, []Value{String("xyz")}},
+		{"no change", 2, 2, nil, values},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected := mustTuple(NewTuple(Format_7_18, test.expected...))
+
+			actual, err := tpl.ReplaceRange(test.start, test.end, test.values...)
+			require.NoError(t, err)
+			assert.True(t, expected.Equals(actual))
+			assert.Equal(t, uint64(len(test.expected)), actual.Len())
+		})
+	}
+
+	assert.Panics(t, func() {
+		_, _ = tpl.ReplaceRange(2, 1)
+	})
+	assert.Panics(t, func() {
+		_, _ = tpl.ReplaceRange(3, 5, Int(0))
+	})
+}
+
+func TestDiffTupleFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        []Value
+		b        []Value
+		expected []TupleFieldDiff
+	}{
+		{
+			"equal",
+			[]Value{String("abc"), Int(1)},
+			[]Value{String("abc"), Int(1)},
+			nil,
+		},
+		{
+			"empty",
+			[]Value{},
+			[]Value{},
+			nil,
+		},
+		{
+			"mid field change",
+			[]Value{String("abc"), Int(1), Uint(2)},
+			[]Value{String("abc"), Int(5), Uint(2)},
+			[]TupleFieldDiff{{1, Int(1), Int(5)}},
+		},
+		{
+			"null change",
+			[]Value{String("abc"), NullValue},
+			[]Value{NullValue, Int(1)},
+			[]TupleFieldDiff{{0, String("abc"), NullValue}, {1, NullValue, Int(1)}},
+		},
+		{
+			"insertions at end",
+			[]Value{String("abc")},
+			[]Value{String("abc"), Int(1), Int(2)},
+			[]TupleFieldDiff{{1, nil, Int(1)}, {2, nil, Int(2)}},
+		},
+		{
+			"removals at end",
+			[]Value{String("abc"), Int(1), Int(2)},
+			[]Value{String("abd")},
+			[]TupleFieldDiff{{0, String("abc"), String("abd")}, {1, Int(1), nil}, {2, Int(2), nil}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, err := NewTuple(Format_7_18, test.a...)
+			require.NoError(t, err)
+			b, err := NewTuple(Format_7_18, test.b...)
+			require.NoError(t, err)
+
+			diffs, err := DiffTupleFields(Format_7_18, a, b)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, diffs)
+		})
+	}
+}
+
+func TestTupleFirstDifference(t *testing.T) {
+	tests := []struct {
+		a       []Value
+		b       []Value
+		index   uint64
+		differs bool
+	}{
+		{[]Value{}, []Value{}, 0, false},
+		{[]Value{Int(1)}, []Value{}, 0, false},
+		{[]Value{Int(1), Int(2)}, []Value{Int(1), Int(2)}, 2, false},
+		{[]Value{Int(1), Int(2)}, []Value{Int(1), Int(2), Int(3)}, 2, false},
+		{[]Value{Int(1), Int(2), Int(3)}, []Value{Int(1)}, 1, false},
+		{[]Value{Int(1), Int(2)}, []Value{Int(2), Int(2)}, 0, true},
+		{[]Value{String("abc"), Int(2), Int(3)}, []Value{String("abc"), Int(2), Int(4)}, 2, true},
+		{[]Value{String("abc"), NullValue}, []Value{String("abc"), Int(2), Int(4)}, 1, true},
+		{[]Value{Int(1), Uint(2)}, []Value{Int(1), Int(2)}, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v|%v", test.a, test.b), func(t *testing.T) {
+			a, err := NewTuple(Format_7_18, test.a...)
+			require.NoError(t, err)
+			b, err := NewTuple(Format_7_18, test.b...)
+			require.NoError(t, err)
+
+			idx, differs, err := a.FirstDifference(b)
+			require.NoError(t, err)
+			assert.Equal(t, test.index, idx)
+			assert.Equal(t, test.differs, differs)
+
+			idx, differs, err = b.FirstDifference(a)
+			require.NoError(t, err)
+			assert.Equal(t, test.index, idx)
+			assert.Equal(t, test.differs, differs)
+		})
+	}
+}
+
+func TestTupleWalkValuesErrCancel(t *testing.T) {
+	vals := make([]Value, 10000)
+	for i := range vals {
+		vals[i] = Int(i)
+	}
+
+	tpl, err := NewTuple(Format_7_18, vals...)
+	require.NoError(t, err)
+
+	var walked []Value
+	err = tpl.WalkValuesErr(context.Background(), func(v Value) error {
+		walked = append(walked, v)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Len(t, walked, len(vals))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	walked = walked[:0]
+	err = tpl.WalkValuesErr(ctx, func(v Value) error {
+		walked = append(walked, v)
+
+		if len(walked) == 100 {
+			cancel()
+		}
+
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, walked, 100)
+
+	// WalkValues stops the walk without returning the context's error
+	err = tpl.WalkValues(ctx, func(v Value) error {
+		t.Fatal("callback should not be called with a canceled context")
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// errors from the callback are still returned by WalkValues
+	cbErr := errors.New("callback error")
+	err = tpl.WalkValues(context.Background(), func(v Value) error {
+		return cbErr
+	})
+	assert.Equal(t, cbErr, err)
+}
+
+func TestThreeWayTupleDiff(t *testing.T) {
+	nbf := Format_7_18
+	base := mustTuple(NewTuple(nbf, Int(0), String("a"), Int(2), NullValue, Int(4)))
+
+	tests := []struct {
+		name      string
+		ours      Tuple
+		theirs    Tuple
+		merged    Tuple
+		conflicts []uint64
+	}{
+		{
+			"no changes",
+			base,
+			base,
+			base,
+			nil,
+		},
+		{
+			"changes on different fields",
+			mustTuple(NewTuple(nbf, Int(10), String("a"), Int(2), NullValue, Int(4))),
+			mustTuple(NewTuple(nbf, Int(0), String("a"), Int(2), String("x"), Int(4))),
+			mustTuple(NewTuple(nbf, Int(10), String("a"), Int(2), String("x"), Int(4))),
+			nil,
+		},
+		{
+			"same change on both sides",
+			mustTuple(NewTuple(nbf, Int(0), String("b"), Int(2), NullValue, Int(4))),
+			mustTuple(NewTuple(nbf, Int(0), String("b"), Int(2), NullValue, Int(5))),
+			mustTuple(NewTuple(nbf, Int(0), String("b"), Int(2), NullValue, Int(5))),
+			nil,
+		},
+		{
+			"conflicting changes",
+			mustTuple(NewTuple(nbf, Int(1), String("b"), Int(2), NullValue, Uint(4))),
+			mustTuple(NewTuple(nbf, Int(0), String("c"), Int(3), NullValue, Int(40))),
+			mustTuple(NewTuple(nbf, Int(1), String("a"), Int(3), NullValue, Int(4))),
+			[]uint64{1, 4},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, conflicts, err := ThreeWayTupleDiff(nbf, base, test.ours, test.theirs)
+			require.NoError(t, err)
+			assert.True(t, test.merged.Equals(merged))
+			assert.Equal(t, test.conflicts, conflicts)
+		})
+	}
+
+	short := mustTuple(NewTuple(nbf, Int(0)))
+	_, _, err := ThreeWayTupleDiff(nbf, base, short, base)
+	assert.Error(t, err)
+}
+
+func TestTupleEqualsAt(t *testing.T) {
+	nested := mustTuple(NewTuple(Format_7_18, Uint(2), Float(3.5)))
+	tpl := mustTuple(NewTuple(Format_7_18, Int(1), String("abc"), NullValue, nested))
+	other := mustTuple(NewTuple(Format_7_18, nested, String("abc"), Int(1), Int(2)))
+
+	tests := []struct {
+		n, m     uint64
+		expected bool
+	}{
+		{0, 2, true},
+		{1, 1, true},
+		{3, 0, true},
+		{0, 3, false},
+		{0, 1, false},
+		{2, 2, false},
+		{3, 1, false},
+	}
+
+	for _, test := range tests {
+		eq, err := tpl.EqualsAt(test.n, other, test.m)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, eq, "n: %d, m: %d", test.n, test.m)
+
+		eq, err = other.EqualsAt(test.m, tpl, test.n)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, eq, "n: %d, m: %d", test.n, test.m)
+	}
+
+	eq, err := tpl.EqualsAt(2, tpl, 2)
+	require.NoError(t, err)
+	assert.True(t, eq)
+
+	assert.Panics(t, func() {
+		_, _ = tpl.EqualsAt(4, other, 0)
+	})
+	assert.Panics(t, func() {
+		_, _ = tpl.EqualsAt(0, other, 4)
+	})
+	assert.Panics(t, func() {
+		_, _ = tpl.EqualsAt(0, EmptyTuple(Format_7_18), 0)
+	})
+}
+
+func TestTupleFieldBytes(t *testing.T) {
+	vals := []Value{Int(1), String("abc"), NullValue, mustTuple(NewTuple(Format_7_18, Uint(2), Float(3.5))), Bool(true)}
+	tpl := mustTuple(NewTuple(Format_7_18, vals...))
+
+	var concatenated []byte
+	for i, val := range vals {
+		raw, err := tpl.FieldBytes(uint64(i))
+		require.NoError(t, err)
+
+		single := mustTuple(NewTuple(Format_7_18, val))
+		expected, err := single.FieldBytes(0)
+		require.NoError(t, err)
+		assert.Equal(t, expected, raw)
+
+		appended, err := EmptyTuple(Format_7_18).AppendEncoded(raw)
+		require.NoError(t, err)
+		assert.True(t, single.Equals(appended))
+
+		concatenated = append(concatenated, raw...)
+	}
+
+	dec, _ := tpl.decoderSkipToFields()
+	assert.Equal(t, dec.buff[dec.offset:], concatenated)
+
+	assert.Panics(t, func() {
+		_, _ = tpl.FieldBytes(uint64(len(vals)))
+	})
+}
+
+func TestTupleByteOffsetOf(t *testing.T) {
+	vals := []Value{Int(1), String("abc"), NullValue, mustTuple(NewTuple(Format_7_18, Uint(2), Float(3.5))), Bool(true)}
+	tpl := mustTuple(NewTuple(Format_7_18, vals...))
+	buff := tpl.buff
+
+	err := tpl.IterFieldSpans(func(index uint64, raw []byte) bool {
+		offset, err := tpl.ByteOffsetOf(index)
+		require.NoError(t, err)
+		assert.Equal(t, raw, buff[offset:offset+len(raw)])
+
+		next, err := tpl.ByteOffsetOf(index + 1)
+		require.NoError(t, err)
+		assert.Equal(t, offset+len(raw), next)
+		return false
+	})
+	require.NoError(t, err)
+
+	end, err := tpl.ByteOffsetOf(tpl.Len())
+	require.NoError(t, err)
+	assert.Equal(t, len(buff), end)
+
+	dec, _ := tpl.decoderSkipToFields()
+	start, err := tpl.ByteOffsetOf(0)
+	require.NoError(t, err)
+	assert.Equal(t, int(dec.offset), start)
+
+	empty := EmptyTuple(Format_7_18)
+	end, err = empty.ByteOffsetOf(0)
+	require.NoError(t, err)
+	assert.Equal(t, len(empty.buff), end)
+
+	assert.Panics(t, func() {
+		_, _ = tpl.ByteOffsetOf(tpl.Len() + 1)
+	})
+}
+
+func TestTupleIterFieldSpans(t *testing.T) {
+	vals := []Value{Int(1), String("abc"), NullValue, mustTuple(NewTuple(Format_7_18, Uint(2), Float(3.5))), Bool(true)}
+	tpl := mustTuple(NewTuple(Format_7_18, vals...))
+
+	var concatenated []byte
+	var indexes []uint64
+	err := tpl.IterFieldSpans(func(index uint64, raw []byte) bool {
+		expected, err := tpl.FieldBytes(index)
+		require.NoError(t, err)
+		assert.Equal(t, expected, raw)
+
+		indexes = append(indexes, index)
+		concatenated = append(concatenated, raw...)
+		return false
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4}, indexes)
+
+	dec, _ := tpl.decoderSkipToFields()
+	assert.Equal(t, dec.buff[dec.offset:], concatenated)
+
+	indexes = nil
+	err = tpl.IterFieldSpans(func(index uint64, raw []byte) bool {
+		indexes = append(indexes, index)
+		return index == 1
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{0, 1}, indexes)
+
+	err = EmptyTuple(Format_7_18).IterFieldSpans(func(index uint64, raw []byte) bool {
+		t.Fatal("unexpected field of empty tuple")
+		return true
+	})
+	require.NoError(t, err)
+}
+
+func TestTupleClone(t *testing.T) {
+	tpl := mustTuple(NewTuple(Format_7_18, Int(1), String("abc"), Float(2.5)))
+
+	page := make([]byte, 0, 3*len(tpl.buff))
+	page = append(page, tpl.buff...)
+	page = append(page, tpl.buff...)
+	page = append(page, tpl.buff...)
+
+	dec := newValueDecoder(page, nil)
+	_, err := readTuple(Format_7_18, &dec)
+	require.NoError(t, err)
+	shared, err := readTuple(Format_7_18, &dec)
+	require.NoError(t, err)
+	require.True(t, tpl.Equals(shared))
+
+	clone := shared.Clone()
+	assert.True(t, tpl.Equals(clone))
+	assert.Equal(t, len(clone.buff), cap(clone.buff))
+
+	for i := range page {
+		page[i] = 0
+	}
+
+	assert.False(t, tpl.Equals(shared))
+	assert.True(t, tpl.Equals(clone))
+
+	val, err := clone.Get(1)
+	require.NoError(t, err)
+	assert.Equal(t, String("abc"), val)
+}
+
+func TestChainTupleIterators(t *testing.T) {
+	nbf := Format_7_18
+	empty := EmptyTuple(nbf)
+	a := mustTuple(NewTuple(nbf, Int(0), Int(1)))
+	b := mustTuple(NewTuple(nbf, String("two")))
+	c := mustTuple(NewTuple(nbf, Float(3), NullValue, Bool(true)))
+
+	iterators := func(tuples ...Tuple) []*TupleIterator {
+		iters := make([]*TupleIterator, len(tuples))
+		for i, tpl := range tuples {
+			itr, err := tpl.Iterator()
+			require.NoError(t, err)
+			iters[i] = itr
+		}
+		return iters
+	}
+
+	tests := []struct {
+		name     string
+		tuples   []Tuple
+		expected []Value
+	}{
+		{"none", nil, nil},
+		{"only empty", []Tuple{empty, empty}, nil},
+		{"two", []Tuple{a, b}, []Value{Int(0), Int(1), String("two")}},
+		{"three", []Tuple{a, b, c}, []Value{Int(0), Int(1), String("two"), Float(3), NullValue, Bool(true)}},
+		{"with empty", []Tuple{empty, a, empty, empty, c, empty}, []Value{Int(0), Int(1), Float(3), NullValue, Bool(true)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			itr := ChainTupleIterators(iterators(test.tuples...)...)
+			assert.Equal(t, uint64(len(test.expected)), itr.Len())
+
+			var actual []Value
+			for itr.HasMore() {
+				expectedPos := uint64(len(actual))
+				assert.Equal(t, expectedPos, itr.Pos())
+
+				pos, val, err := itr.Next()
+				require.NoError(t, err)
+				assert.Equal(t, expectedPos, pos)
+				actual = append(actual, val)
+			}
+
+			assert.Equal(t, test.expected, actual)
+			assert.Equal(t, itr.Len(), itr.Pos())
+
+			pos, val, err := itr.Next()
+			require.NoError(t, err)
+			assert.Equal(t, itr.Len(), pos)
+			assert.Nil(t, val)
+		})
+	}
+
+	itr, err := a.IteratorAt(1)
+	require.NoError(t, err)
+	chained := ChainTupleIterators(append([]*TupleIterator{itr}, iterators(b)...)...)
+	assert.Equal(t, uint64(1), chained.Pos())
+
+	pos, val, err := chained.Next()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), pos)
+	assert.Equal(t, Int(1), val)
+
+	pos, val, err = chained.Next()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), pos)
+	assert.Equal(t, String("two"), val)
+}
+
+func TestTupleLessWithNulls(t *testing.T) {
+	nbf := Format_7_18
+	tests := []struct {
+		a          []Value
+		b          []Value
+		nullsFirst bool
+		nullsLast  bool
+	}{
+		{[]Value{NullValue}, []Value{Int(1)}, true, false},
+		{[]Value{Int(1)}, []Value{NullValue}, false, true},
+		{[]Value{NullValue}, []Value{NullValue}, false, false},
+		{[]Value{NullValue, Int(1)}, []Value{NullValue, Int(2)}, true, true},
+		{[]Value{NullValue, Int(2)}, []Value{NullValue, Int(1)}, false, false},
+		{[]Value{Int(1), NullValue}, []Value{Int(1), String("a")}, true, false},
+		{[]Value{Int(1), NullValue}, []Value{Int(2), NullValue}, true, true},
+		{[]Value{Int(1), Int(2)}, []Value{Int(1), Int(3)}, true, true},
+		{[]Value{NullValue}, []Value{NullValue, Int(1)}, true, true},
+		{[]Value{NullValue, Int(1)}, []Value{NullValue}, false, false},
+		{[]Value{String("a"), NullValue}, []Value{String("a"), Bool(false)}, true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v|%v", test.a, test.b), func(t *testing.T) {
+			a := mustTuple(NewTuple(nbf, test.a...))
+			b := mustTuple(NewTuple(nbf, test.b...))
+
+			less, err := a.LessWithNulls(nbf, b, true)
+			require.NoError(t, err)
+			assert.Equal(t, test.nullsFirst, less)
+
+			less, err = a.LessWithNulls(nbf, b, false)
+			require.NoError(t, err)
+			assert.Equal(t, test.nullsLast, less)
+
+			if !IsNull(test.a[0]) && !IsNull(test.b[0]) && !test.a[0].Equals(test.b[0]) {
+				// without a NULL in the deciding field the ordering matches Less
+				expected, err := a.Less(nbf, b)
+				require.NoError(t, err)
+				assert.Equal(t, expected, test.nullsFirst)
+			}
+		})
+	}
+}
+
+func TestTupleLessThanEncoded(t *testing.T) {
+	nbf := Format_7_18
+	rnd := rand.New(rand.NewSource(0))
+
+	randomValue := func() Value {
+		switch rnd.Intn(6) {
+		case 0:
+			return Int(rnd.Intn(3))
+		case 1:
+			return Uint(rnd.Intn(3))
+		case 2:
+			return String([]string{"", "a", "b"}[rnd.Intn(3)])
+		case 3:
+			return NullValue
+		case 4:
+			return Bool(rnd.Intn(2) == 0)
+		default:
+			return mustTuple(NewTuple(nbf, Int(rnd.Intn(2)), String("a")))
+		}
+	}
+	randomTuple := func() Tuple {
+		vals := make([]Value, rnd.Intn(4))
+		for i := range vals {
+			vals[i] = randomValue()
+		}
+		return mustTuple(NewTuple(nbf, vals...))
+	}
+
+	for i := 0; i < 5000; i++ {
+		a, b := randomTuple(), randomTuple()
+		if rnd.Intn(4) == 0 {
+			// share a prefix so that leading fields are skipped
+			b = mustTuple(a.Append(randomValue()))
+		}
+
+		expected, err := a.Less(nbf, b)
+		require.NoError(t, err)
+		less, err := a.LessThanEncoded(nbf, b.buff)
+		require.NoError(t, err)
+		require.Equal(t, expected, less, "iteration %d", i)
+	}
+
+	a := mustTuple(NewTuple(nbf, Int(1)))
+	_, err := a.LessThanEncoded(nbf, nil)
+	assert.Equal(t, ErrInvalidEncodedValue, err)
+	rawInt, err := a.FieldBytes(0)
+	require.NoError(t, err)
+	_, err = a.LessThanEncoded(nbf, rawInt)
+	assert.Equal(t, ErrInvalidEncodedValue, err)
+}
+
+func TestTupleCompare(t *testing.T) {
+	nbf := Format_7_18
+	rnd := rand.New(rand.NewSource(0))
+
+	randomValue := func() Value {
+		switch rnd.Intn(5) {
+		case 0:
+			return Int(rnd.Intn(3))
+		case 1:
+			return String([]string{"", "a", "b"}[rnd.Intn(3)])
+		case 2:
+			return NullValue
+		case 3:
+			return Bool(rnd.Intn(2) == 0)
+		default:
+			return mustTuple(NewTuple(nbf, Int(rnd.Intn(2)), String("a")))
+		}
+	}
+	randomTuple := func() Tuple {
+		vals := make([]Value, rnd.Intn(4))
+		for i := range vals {
+			vals[i] = randomValue()
+		}
+		return mustTuple(NewTuple(nbf, vals...))
+	}
+
+	for i := 0; i < 5000; i++ {
+		a, b := randomTuple(), randomTuple()
+		switch rnd.Intn(4) {
+		case 0:
+			// b extends a, so a is ordered first by the prefix rule
+			b = mustTuple(a.Append(randomValue()))
+		case 1:
+			b = a.Clone()
+		}
+
+		c, err := a.Compare(nbf, b)
+		require.NoError(t, err)
+		reverse, err := b.Compare(nbf, a)
+		require.NoError(t, err)
+		require.Equal(t, -c, reverse, "iteration %d", i)
+		require.Equal(t, a.Equals(b), c == 0, "iteration %d", i)
+
+		// LessThanEncoded orders tuples without delegating to Compare
+		less, err := a.LessThanEncoded(nbf, b.buff)
+		require.NoError(t, err)
+		require.Equal(t, less, c < 0, "iteration %d", i)
+		greater, err := b.LessThanEncoded(nbf, a.buff)
+		require.NoError(t, err)
+		require.Equal(t, greater, c > 0, "iteration %d", i)
+
+		isLess, err := a.Less(nbf, b)
+		require.NoError(t, err)
+		require.Equal(t, isLess, c < 0, "iteration %d", i)
+	}
+
+	short := mustTuple(NewTuple(nbf, Int(1), String("a")))
+	long := mustTuple(NewTuple(nbf, Int(1), String("a"), Int(0)))
+	c, err := short.Compare(nbf, long)
+	require.NoError(t, err)
+	assert.Equal(t, -1, c)
+	c, err = long.Compare(nbf, short)
+	require.NoError(t, err)
+	assert.Equal(t, 1, c)
+	c, err = EmptyTuple(nbf).Compare(nbf, EmptyTuple(nbf))
+	require.NoError(t, err)
+	assert.Equal(t, 0, c)
+}
+
+func TestTupleLessWith(t *testing.T) {
+	nbf := Format_7_18
+	asc, desc := DefaultFieldComparator, DescendingFieldComparator(nil)
+
+	tests := []struct {
+		name     string
+		a        []Value
+		b        []Value
+		cmps     []FieldComparator
+		expected bool
+	}{
+		{"ascending", []Value{Int(1)}, []Value{Int(2)}, []FieldComparator{asc}, true},
+		{"descending", []Value{Int(1)}, []Value{Int(2)}, []FieldComparator{desc}, false},
+		{"descending reversed", []Value{Int(2)}, []Value{Int(1)}, []FieldComparator{desc}, true},
+		{"equal", []Value{Int(1)}, []Value{Int(1)}, []FieldComparator{desc}, false},
+		{"asc then desc decided by first", []Value{Int(1), Int(1)}, []Value{Int(2), Int(2)}, []FieldComparator{asc, desc}, true},
+		{"asc then desc decided by second", []Value{Int(1), Int(2)}, []Value{Int(1), Int(1)}, []FieldComparator{asc, desc}, true},
+		{"asc then desc decided by second reversed", []Value{Int(1), Int(1)}, []Value{Int(1), Int(2)}, []FieldComparator{asc, desc}, false},
+		{"desc then asc", []Value{Int(2), Int(2)}, []Value{Int(1), Int(1)}, []FieldComparator{desc, asc}, true},
+		{"desc then asc decided by second", []Value{Int(1), Int(1)}, []Value{Int(1), Int(2)}, []FieldComparator{desc, asc}, true},
+		{"fewer comparators than fields", []Value{Int(1), Int(1)}, []Value{Int(1), Int(2)}, []FieldComparator{desc}, true},
+		{"nil comparator", []Value{Int(2), Int(1)}, []Value{Int(1), Int(2)}, []FieldComparator{nil, desc}, false},
+		{"no comparators", []Value{String("a")}, []Value{String("b")}, nil, true},
+		{"shorter is less", []Value{Int(1)}, []Value{Int(1), Int(0)}, []FieldComparator{desc, desc}, true},
+		{"longer is not less", []Value{Int(1), Int(0)}, []Value{Int(1)}, []FieldComparator{desc, desc}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := mustTuple(NewTuple(nbf, test.a...))
+			b := mustTuple(NewTuple(nbf, test.b...))
+
+			less, err := a.LessWith(nbf, b, test.cmps)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, less)
+		})
+	}
+
+	// with only default comparators the ordering matches Less
+	a := mustTuple(NewTuple(nbf, Int(1), String("b")))
+	b := mustTuple(NewTuple(nbf, Int(1), String("c")))
+	expected, err := a.Less(nbf, b)
+	require.NoError(t, err)
+	less, err := a.LessWith(nbf, b, []FieldComparator{asc, asc})
+	require.NoError(t, err)
+	assert.Equal(t, expected, less)
+}
+
+func TestTupleEqualsNumericTolerant(t *testing.T) {
+	tests := []struct {
+		a        []Value
+		b        []Value
+		expected bool
+	}{
+		{[]Value{}, []Value{}, true},
+		{[]Value{Int(1), String("a")}, []Value{Int(1), String("a")}, true},
+		{[]Value{Int(1), String("a")}, []Value{Float(1), String("a")}, true},
+		{[]Value{Uint(1), String("a")}, []Value{Float(1), String("a")}, true},
+		{[]Value{Int(1), String("a")}, []Value{Uint(1), String("a")}, true},
+		{[]Value{Int(-3)}, []Value{Float(-3)}, true},
+		{[]Value{Float(0)}, []Value{Int(0)}, true},
+		{[]Value{Int(1)}, []Value{Float(1.5)}, false},
+		{[]Value{Int(-1)}, []Value{Uint(math.MaxUint64)}, false},
+		{[]Value{Int(math.MaxInt64)}, []Value{Float(math.MaxInt64)}, false},
+		{[]Value{Uint(math.MaxUint64)}, []Value{Float(math.MaxUint64)}, false},
+		{[]Value{Int(1 << 53)}, []Value{Float(1 << 53)}, true},
+		{[]Value{Int(1<<53 + 1)}, []Value{Float(1 << 53)}, false},
+		{[]Value{Int(1), String("a")}, []Value{Int(1), String("b")}, false},
+		{[]Value{Int(1)}, []Value{String("1")}, false},
+		{[]Value{Int(1)}, []Value{Bool(true)}, false},
+		{[]Value{Int(1)}, []Value{NullValue}, false},
+		{[]Value{Int(1)}, []Value{Float(1), Int(2)}, false},
+		{[]Value{mustTuple(NewTuple(Format_7_18, Int(1)))}, []Value{mustTuple(NewTuple(Format_7_18, Float(1)))}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v|%v", test.a, test.b), func(t *testing.T) {
+			a := mustTuple(NewTuple(Format_7_18, test.a...))
+			b := mustTuple(NewTuple(Format_7_18, test.b...))
+
+			eq, err := a.EqualsNumericTolerant(b)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, eq)
+
+			eq, err = b.EqualsNumericTolerant(a)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, eq)
+		})
+	}
+
+	// NaN can't be encoded in a tuple, so it is checked against the comparison directly.
+	nan := Float(math.NaN())
+	assert.False(t, numericValuesEqual(nan, nan))
+	assert.False(t, numericValuesEqual(nan, Int(0)))
+	assert.False(t, numericValuesEqual(Int(0), nan))
+	assert.False(t, numericValuesEqual(Uint(0), nan))
+}
+
+func TestTupleFieldTypeHistogram(t *testing.T) {
+	nbf := Format_7_18
+	tuples := []Tuple{
+		mustTuple(NewTuple(nbf, Int(1), String("a"), Float(1))),
+		mustTuple(NewTuple(nbf, Int(2), NullValue)),
+		mustTuple(NewTuple(nbf, Uint(3), String("c"), Float(3), Bool(true))),
+		mustTuple(NewTuple(nbf)),
+		mustTuple(NewTuple(nbf, Int(4), String("d"), mustTuple(NewTuple(nbf, Int(5))))),
+	}
+
+	hist, err := TupleFieldTypeHistogram(nbf, tuples)
+	require.NoError(t, err)
+
+	expected := []map[NomsKind]uint64{
+		{IntKind: 3, UintKind: 1},
+		{StringKind: 3, NullKind: 1},
+		{FloatKind: 2, TupleKind: 1},
+		{BoolKind: 1},
+	}
+	assert.Equal(t, expected, hist)
+
+	hist, err = TupleFieldTypeHistogram(nbf, nil)
+	require.NoError(t, err)
+	assert.Empty(t, hist)
+}
+
+func TestTupleForEachPair(t *testing.T) {
+	nbf := Format_7_18
+	tpl := mustTuple(NewTuple(nbf, String("a"), Int(1), String("b"), NullValue, Int(3), String("c")))
+
+	var keys, vals []Value
+	err := tpl.ForEachPair(func(k, v Value) (stop bool, err error) {
+		keys = append(keys, k)
+		vals = append(vals, v)
+		return false, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Value{String("a"), String("b"), Int(3)}, keys)
+	assert.Equal(t, []Value{Int(1), NullValue, String("c")}, vals)
+
+	count := 0
+	err = tpl.ForEachPair(func(k, v Value) (stop bool, err error) {
+		count++
+		return count == 2, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	cbErr := errors.New("callback error")
+	err = tpl.ForEachPair(func(k, v Value) (stop bool, err error) {
+		return false, cbErr
+	})
+	assert.Equal(t, cbErr, err)
+
+	err = EmptyTuple(nbf).ForEachPair(func(k, v Value) (stop bool, err error) {
+		t.Fatal("callback should not be called for an empty tuple")
+		return false, nil
+	})
+	require.NoError(t, err)
+
+	odd := mustTuple(NewTuple(nbf, String("a"), Int(1), String("b")))
+	err = odd.ForEachPair(func(k, v Value) (stop bool, err error) {
+		t.Fatal("callback should not be called for an odd number of fields")
+		return false, nil
+	})
+	assert.Equal(t, ErrOddNumberOfFields, err)
+}
+
+func TestTupleFieldsAreOrdered(t *testing.T) {
+	tests := []struct {
+		vals     []Value
+		expected bool
+	}{
+		{[]Value{}, true},
+		{[]Value{Int(1)}, true},
+		{[]Value{Int(1), Int(2), Int(3)}, true},
+		{[]Value{Int(1), Int(1), Int(2)}, true},
+		{[]Value{Int(1), Int(3), Int(2)}, false},
+		{[]Value{Int(2), Int(1)}, false},
+		{[]Value{String("a"), String("b"), String("b")}, true},
+		{[]Value{String("b"), String("a")}, false},
+		{[]Value{Float(-1.5), Float(0), Float(2.25)}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.vals), func(t *testing.T) {
+			tpl := mustTuple(NewTuple(Format_7_18, test.vals...))
+			ordered, err := tpl.FieldsAreOrdered(Format_7_18)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, ordered)
+		})
+	}
+}
+
+func TestTupleGetWithType(t *testing.T) {
+	nbf := Format_7_18
+	nested := mustTuple(NewTuple(nbf, Int(1), String("a")))
+	vrw := newTestValueStore()
+	st, err := NewStruct(nbf, "S", StructData{"a": Int(1), "b": String("b")})
+	require.NoError(t, err)
+	l, err := NewList(context.Background(), vrw, Int(1), Int(2))
+	require.NoError(t, err)
+	vals := []Value{Int(1), String("abc"), NullValue, Float(2.5), nested, Bool(false), st, l, Uint(3)}
+	tpl := mustTuple(NewTuple(nbf, vals...))
+
+	for i, expected := range vals {
+		v, typ, err := tpl.GetWithType(uint64(i))
+		require.NoError(t, err)
+		assert.True(t, expected.Equals(v))
+
+		expectedType, err := TypeOf(expected)
+		require.NoError(t, err)
+		assert.True(t, expectedType.Equals(typ))
+	}
+
+	assert.Panics(t, func() {
+		_, _, _ = tpl.GetWithType(uint64(len(vals)))
+	})
+}
+
+func TestTupleWithFormat(t *testing.T) {
+	nested := mustTuple(NewTuple(Format_7_18, Float(-0.25), String("nested")))
+	tpl := mustTuple(NewTuple(Format_7_18, Int(-7), Float(1.5), String("a"), NullValue, Bool(true), nested))
+
+	same, err := tpl.WithFormat(Format_7_18)
+	require.NoError(t, err)
+	assert.True(t, &tpl.buff[0] == &same.buff[0], "a tuple already in the format should not be copied")
+
+	ld1, err := tpl.WithFormat(Format_LD_1)
+	require.NoError(t, err)
+	assert.Equal(t, Format_LD_1, ld1.Format())
+	assert.Equal(t, tpl.Len(), ld1.Len())
+	// floats are encoded differently by each format
+	assert.NotEqual(t, tpl.buff, ld1.buff)
+
+	ldNested, err := ld1.Get(5)
+	require.NoError(t, err)
+	assert.Equal(t, Format_LD_1, ldNested.(Tuple).Format())
+
+	roundTrip, err := ld1.WithFormat(Format_7_18)
+	require.NoError(t, err)
+	assert.Equal(t, Format_7_18, roundTrip.Format())
+	assert.True(t, tpl.Equals(roundTrip))
+
+	for i := uint64(0); i < 5; i++ {
+		expected, err := tpl.Get(i)
+		require.NoError(t, err)
+		actual, err := ld1.Get(i)
+		require.NoError(t, err)
+		assert.True(t, expected.Equals(actual), "field %d", i)
+	}
+
+	nestedFloat, err := ldNested.(Tuple).Get(0)
+	require.NoError(t, err)
+	assert.Equal(t, Float(-0.25), nestedFloat)
+}
+
+func TestTupleTypedGetters(t *testing.T) {
+	tpl := mustTuple(NewTuple(Format_7_18, String("abc"), Float(-2.5), Bool(true), Int(-7), Uint(7), NullValue))
+
+	s, err := tpl.GetString(0)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", s)
+
+	f, err := tpl.GetFloat(1)
+	require.NoError(t, err)
+	assert.Equal(t, -2.5, f)
+
+	b, err := tpl.GetBool(2)
+	require.NoError(t, err)
+	assert.True(t, b)
+
+	i, err := tpl.GetInt(3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-7), i)
+
+	_, err = tpl.GetString(1)
+	assert.True(t, errors.Is(err, ErrUnexpectedFieldKind))
+	assert.Contains(t, err.Error(), "field 1 is a Float, not a String")
+
+	_, err = tpl.GetFloat(3)
+	assert.True(t, errors.Is(err, ErrUnexpectedFieldKind))
+
+	_, err = tpl.GetBool(0)
+	assert.True(t, errors.Is(err, ErrUnexpectedFieldKind))
+
+	// a Uint is not an Int
+	_, err = tpl.GetInt(4)
+	assert.True(t, errors.Is(err, ErrUnexpectedFieldKind))
+
+	_, err = tpl.GetInt(5)
+	assert.True(t, errors.Is(err, ErrUnexpectedFieldKind))
+
+	assert.Panics(t, func() {
+		_, _ = tpl.GetString(6)
+	})
+}
+
+func TestTupleLast(t *testing.T) {
+	nbf := Format_7_18
+	nested := mustTuple(NewTuple(nbf, Int(1), String("a")))
+
+	tests := [][]Value{
+		{Int(1)},
+		{Int(1), String("abc")},
+		{String("abc"), NullValue},
+		{Float(2.5), Bool(false), nested},
+		{nested, Uint(7), InlineBlob{1, 2, 3}, String("last")},
+	}
+
+	for _, vals := range tests {
+		t.Run(fmt.Sprintf("%v", vals), func(t *testing.T) {
+			tpl := mustTuple(NewTuple(nbf, vals...))
+
+			expected, err := tpl.Get(tpl.Len() - 1)
+			require.NoError(t, err)
+
+			last, ok, err := tpl.Last()
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.True(t, expected.Equals(last))
+		})
+	}
+
+	last, ok, err := EmptyTuple(nbf).Last()
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Nil(t, last)
+}
+
+func TestTupleFormatWith(t *testing.T) {
+	nbf := Format_7_18
+	nested := mustTuple(NewTuple(nbf, InlineBlob{0xab}, Int(2)))
+	tpl := mustTuple(NewTuple(nbf, Int(1), InlineBlob{0x01, 0xff}, String("abc"), nested))
+
+	hexBlob := func(v Value) string {
+		return fmt.Sprintf("0x%x", []byte(v.(InlineBlob)))
+	}
+
+	var expected strings.Builder
+	expected.WriteString("(")
+	for i, v := range []Value{Int(1), InlineBlob{0x01, 0xff}, String("abc")} {
+		if i != 0 {
+			expected.WriteString(",")
+		}
+		str, err := EncodedValue(context.Background(), v)
+		require.NoError(t, err)
+		expected.WriteString(str)
+	}
+	one, err := EncodedValue(context.Background(), Int(1))
+	require.NoError(t, err)
+
+	// without functions every field is rendered by EncodedValue
+	str, err := tpl.FormatWith(nil)
+	require.NoError(t, err)
+	encodedNested, err := EncodedValue(context.Background(), nested)
+	require.NoError(t, err)
+	assert.Equal(t, expected.String()+","+encodedNested+")", str)
+
+	// a function for blobs is used for nested tuples too
+	str, err = tpl.FormatWith(map[NomsKind]func(Value) string{InlineBlobKind: hexBlob})
+	require.NoError(t, err)
+	abc, err := EncodedValue(context.Background(), String("abc"))
+	require.NoError(t, err)
+	two, err := EncodedValue(context.Background(), Int(2))
+	require.NoError(t, err)
+	assert.Equal(t, "("+one+",0x01ff,"+abc+",(0xab,"+two+"))", str)
+
+	// a function for tuples replaces rendering of nested tuples
+	str, err = tpl.FormatWith(map[NomsKind]func(Value) string{TupleKind: func(Value) string { return "..." }})
+	require.NoError(t, err)
+	assert.Equal(t, expected.String()+",...)", str)
+
+	str, err = EmptyTuple(nbf).FormatWith(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "()", str)
+}
+
+func TestTupleSerialize(t *testing.T) {
+	vrw := newTestValueStore()
+	nbf := vrw.Format()
+
+	tuples := []Tuple{
+		EmptyTuple(nbf),
+		mustTuple(NewTuple(nbf, Int(1), String("abc"), NullValue)),
+		mustTuple(NewTuple(nbf, mustTuple(NewTuple(nbf, Float(1.5))), Bool(true), Uint(7))),
+	}
+
+	for _, tpl := range tuples {
+		b := tpl.Serialize()
+		actual, err := DeserializeTuple(nbf, vrw, b)
+		require.NoError(t, err)
+		assert.True(t, tpl.Equals(actual))
+
+		// the deserialized tuple does not share memory with its input
+		for i := range b {
+			b[i] = 0
+		}
+		assert.True(t, tpl.Equals(actual))
+	}
+}
+
+func TestDeserializeTupleErrors(t *testing.T) {
+	vrw := newTestValueStore()
+	nbf := vrw.Format()
+	tpl := mustTuple(NewTuple(nbf, Int(1), String("abc")))
+	valid := tpl.Serialize()
+
+	headerLen := 3 + int(valid[2])
+	header := valid[:headerLen:headerLen]
+
+	modified := func(f func(b []byte)) []byte {
+		b := make([]byte, len(valid))
+		copy(b, valid)
+		f(b)
+		return b
+	}
+
+	w := newBinaryNomsWriter()
+	require.NoError(t, String("abc").writeTo(&w, nbf))
+
+	tests := []struct {
+		name        string
+		b           []byte
+		expectedErr error
+	}{
+		{"empty", []byte{}, ErrInvalidSerializedTuple},
+		{"bad magic", modified(func(b []byte) { b[0]++ }), ErrInvalidSerializedTuple},
+		{"bad version", modified(func(b []byte) { b[1]++ }), ErrInvalidSerializedTuple},
+		{"truncated header", valid[:3], ErrInvalidSerializedTuple},
+		{"format too long", modified(func(b []byte) { b[2] = 255 }), ErrInvalidSerializedTuple},
+		{"format mismatch", modified(func(b []byte) { b[3]++ }), ErrSerializedTupleFormat},
+		{"header only", header, ErrInvalidSerializedTuple},
+		{"trailing bytes", append(modified(func(b []byte) {}), 0), ErrInvalidSerializedTuple},
+		{"not a tuple", append(header, w.data()...), ErrInvalidSerializedTuple},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DeserializeTuple(nbf, vrw, test.b)
+			assert.Equal(t, test.expectedErr, err)
+		})
+	}
+
+	// a tuple serialized in another format
+	var other *NomsBinFormat
+	if nbf == Format_7_18 {
+		other = Format_LD_1
+	} else {
+		other = Format_7_18
+	}
+	_, err := DeserializeTuple(other, vrw, valid)
+	assert.Equal(t, ErrSerializedTupleFormat, err)
+}